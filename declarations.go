@@ -8,25 +8,63 @@ type DeclFunc struct {
 	Name       string
 	Import     string
 	Receiver   FuncReceiver
+	TypeParams []TypeParam
 	Args       []DeclVar
 	VariadicLastArg bool
 	ReturnArgs []DeclVar
 	BodyTmpl   string
 	BodyData   any
 	DocString  string
+
+	// Directives are written after the DocString (e.g. `//go:noinline`)
+	Directives []string
+
+	// Comment is the trailing line comment of an interface method
+	// (e.g. `// returns the id`).
+	//
+	// It is only written for funcs within a TypeInterface.
+	Comment string
 }
 
 type FuncReceiver struct {
 	VarName   string
 	TypeName  string
 	IsPointer bool
+
+	// TypeParams holds the names of the type parameters of the receiver type
+	// if the receiver is a generic type
+	//
+	// e.g. for `func (s *Set[K, V]) Add()`, TypeParams will be `[K V]`
+	TypeParams []string
 }
 
 type DeclType struct {
-	Name      string
-	Import    string
-	Type      Type
+	Name       string
+	Import     string
+	TypeParams []TypeParam
+	Type       Type
+
+	// IsAlias is true if this is an alias declaration (i.e. `type A = B`)
+	// rather than a type definition (i.e. `type A B`).
+	//
+	// An alias is only another name for `Type`, so unlike a defined type it
+	// does not have its own method set.
+	IsAlias bool
+
 	DocString string
+
+	// Directives are written after the DocString (e.g. `//go:generate`)
+	Directives []string
+}
+
+// TypeParam is a single type parameter of a generic type or function
+// declaration
+//
+// e.g. `func MyFunc[K comparable, V ~int | ~string]()` has two type params;
+// `K` with constraint `comparable` and `V` with constraint `~int | ~string`
+type TypeParam struct {
+	Name       string
+	Constraint Type
 }
 
 type DeclVar struct {
@@ -38,19 +76,104 @@ type DeclVar struct {
 	// (if one was assigned - otherwise it will be empty)
 	//
 	// e.g. for `var MyVar int = 123`, LiteralValue will be `123`
+	//
+	// When parsed, this is the source text of the whole initializer expression
+	// (e.g. `time.Second * 5` or `map[string]int{"a": 1}`)
 	LiteralValue string
 
+	// LiteralValueImports is the set of imports referenced by LiteralValue
+	//
+	// e.g. for `var MyVar = time.Second * 5` this will contain `time`
+	LiteralValueImports map[string]bool
+
+	// Group identifies the declaration block this DeclVar belongs to.
+	//
+	// Consecutive DeclVars with the same Group are written together in a
+	// single parenthesised block. When parsed, each `const` or `var`
+	// declaration in a file is given its own Group, numbered in order of
+	// appearance from 0.
+	Group int
+
+	// GroupDocString and GroupDirectives are the doc string and directives of
+	// the declaration block (e.g. the comment above `const (`), which are
+	// written once above the block.
+	//
+	// They are only used on the first DeclVar of a Group. When parsed, they
+	// are only set for a parenthesised block; the doc of an unparenthesised
+	// declaration (e.g. `// doc` above `const A = 1`) is its DocString.
+	GroupDocString  string
+	GroupDirectives []string
+
+	// SharesSpec is set for a declaration which is declared in the same spec
+	// as the declaration before it, so is written in that spec.
+	//
+	// e.g. for `const ( A, B = iota, iota )`, B has SharesSpec set (and so
+	// keeps the same value of iota as A when written). For a struct field,
+	// it is set on each name after the first of a field (e.g. `B` in
+	// `A, B int`), which is written on the line of the first name with its
+	// type, tag, doc and comment.
+	SharesSpec bool
+
+	// Iota is the value of `iota` for this const declaration; i.e. the index
+	// of its spec within its declaration block.
+	//
+	// e.g. for `const ( A MyEnum = iota; B; C )`, C has Iota 2
+	Iota int
+
+	// IsImplicit is set for a const declaration which omits its type and value
+	// and so implicitly repeats the type and expression of the preceding spec
+	// in its block.
+	//
+	// When parsed, Type, LiteralValue and LiteralValueImports are copied from
+	// the spec being repeated; they are not written for an implicit DeclVar.
+	//
+	// e.g. for `const ( A MyEnum = iota; B; C )`, B and C are implicit with
+	// Type `MyEnum` and LiteralValue `iota`
+	IsImplicit bool
+
+	// SharesLiteralValue is set for a declaration which is assigned one of
+	// the values of a multi-valued initializer; i.e. a spec declaring several
+	// names with a single value expression.
+	//
+	// The initializer is the LiteralValue of the first declaration of the
+	// spec, and each following declaration of the spec has
+	// SharesLiteralValue set and is written in the same spec.
+	//
+	// e.g. for `var a, b = strconv.Atoi("1")`, `a` has LiteralValue
+	// `strconv.Atoi("1")` and `b` has SharesLiteralValue set
+	SharesLiteralValue bool
+
 	// StructTag holds the tags for a struct field if this DeclVar represents a
 	// field within a struct.
 	// If this DeclVar is not within a struct then it is not used.
 	StructTag reflect.StructTag
 
 	DocString string
+
+	// Directives are written after the DocString (e.g.
+	// `//go:embed static/*` for an embedded var)
+	//
+	// When parsed, directives are separated from the DocString of each
+	// declaration, so they are written back in a valid position.
+	Directives []string
+
+	// Comment is the trailing line comment of this declaration
+	// (e.g. `// in seconds`), for a const/var spec or a struct field.
+	Comment string
 }
 
-func (d DeclFunc) RequiredImports() map[string]bool {
+func (d DeclVar) RequiredImports() map[string]bool {
 
 	ret := make(map[string]bool)
+	if d.Type != nil {
+		ret = union(ret, d.Type.RequiredImports())
+	}
+	return union(ret, d.LiteralValueImports)
+}
+
+func (d DeclFunc) RequiredImports() map[string]bool {
+
+	ret := typeParamsRequiredImports(d.TypeParams)
 	for _, arg := range d.Args {
 		ret = union(ret, arg.RequiredImports())
 	}
@@ -59,3 +182,23 @@ func (d DeclFunc) RequiredImports() map[string]bool {
 	}
 	return ret
 }
+
+func (d DeclType) RequiredImports() map[string]bool {
+
+	ret := typeParamsRequiredImports(d.TypeParams)
+	if d.Type != nil {
+		ret = union(ret, d.Type.RequiredImports())
+	}
+	return ret
+}
+
+func typeParamsRequiredImports(typeParams []TypeParam) map[string]bool {
+
+	ret := make(map[string]bool)
+	for _, p := range typeParams {
+		if p.Constraint != nil {
+			ret = union(ret, p.Constraint.RequiredImports())
+		}
+	}
+	return ret
+}
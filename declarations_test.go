@@ -49,6 +49,42 @@ func TestDeclFunc_RequiredImports(t *testing.T) {
 				"import/3": true,
 			},
 		},
+		{
+			Name: "type param constraints",
+			F: gopkg.DeclFunc{
+				TypeParams: []gopkg.TypeParam{
+					{
+						Name: "K",
+						Constraint: gopkg.TypeNamed{
+							Import: "import/1",
+						},
+					},
+					{
+						Name: "V",
+						Constraint: gopkg.TypeUnion{
+							Terms: []gopkg.TypeUnionTerm{
+								{
+									Type: gopkg.TypeNamed{
+										Import: "import/2",
+									},
+								},
+							},
+						},
+					},
+				},
+				Args: []gopkg.DeclVar{
+					{
+						Type: gopkg.TypeNamed{
+							Name: "K",
+						},
+					},
+				},
+			},
+			Expected: map[string]bool{
+				"import/1": true,
+				"import/2": true,
+			},
+		},
 	}
 
 	for _, test := range testCases {
@@ -64,3 +100,60 @@ func TestDeclFunc_RequiredImports(t *testing.T) {
 		})
 	}
 }
+
+func TestDeclType_RequiredImports(t *testing.T) {
+
+	testCases := []struct {
+		Name     string
+		T        gopkg.DeclType
+		Expected map[string]bool
+	}{
+		{
+			Name: "empty type",
+		},
+		{
+			Name: "type and type param constraints",
+			T: gopkg.DeclType{
+				TypeParams: []gopkg.TypeParam{
+					{
+						Name: "T",
+						Constraint: gopkg.TypeNamed{
+							Import: "import/1",
+						},
+					},
+				},
+				Type: gopkg.TypeStruct{
+					Fields: []gopkg.DeclVar{
+						{
+							Type: gopkg.TypeNamed{
+								Import: "import/2",
+							},
+						},
+						{
+							Type: gopkg.TypeNamed{
+								Name: "T",
+							},
+						},
+					},
+				},
+			},
+			Expected: map[string]bool{
+				"import/1": true,
+				"import/2": true,
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			actual := test.T.RequiredImports()
+
+			if len(test.Expected) == 0 && len(actual) == 0 {
+				// Allow actual to be either nil or an empty map
+				return
+			}
+
+			require.Equal(t, test.Expected, actual)
+		})
+	}
+}
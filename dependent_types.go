@@ -0,0 +1,295 @@
+package gopkg
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/packages"
+)
+
+// dependentTypeCache holds the type declarations of every package used to
+// resolve dependent types (see ParseDependentTypes) and dot imports during a
+// parse, so that each package is only loaded once
+type dependentTypeCache struct {
+	pkgs map[string]*dependentTypePackage
+
+	// resolving is the set of types (by `importPath.Name`) which are
+	// currently being resolved; these are not resolved again, so that
+	// recursive types terminate
+	resolving map[string]bool
+
+	// cuts is the number of times a type has not been resolved because it
+	// was already being resolved
+	cuts int
+
+	// completed holds the resolved types (by `importPath.Name`, and the depth
+	// when it is limited) which did not refer to any type being resolved, so
+	// can be reused wherever they are referred to (this stops the resolving
+	// of types which are referred to many times from being exponential)
+	completed map[string]Type
+}
+
+type dependentTypePackage struct {
+	types map[string]dependentTypeDecl
+
+	// err is the error from loading the package, if any
+	err error
+}
+
+type dependentTypeDecl struct {
+	spec *ast.TypeSpec
+
+	// fileImports are the local aliases of the imports in the file which
+	// declares the type (see buildFileAliasesAndImports)
+	fileImports map[string]string
+}
+
+func newDependentTypeCache() *dependentTypeCache {
+	return &dependentTypeCache{
+		pkgs:      make(map[string]*dependentTypePackage),
+		resolving: make(map[string]bool),
+		completed: make(map[string]Type),
+	}
+}
+
+// addPackageSources adds the types declared in `sources` (a map of filename to
+// source) to the cache as the package `pkgImportPath`
+//
+// This is used for the package being parsed, which may not be loadable by
+// its import path (e.g. when the import path is set with
+// ParseWithPkgImportPath). Files of any package other than
+// `primaryPackage` (e.g. an external test package) are added under their own
+// import path (see packageImportPathOf).
+func (c *dependentTypeCache) addPackageSources(
+	pkgImportPath string,
+	primaryPackage string,
+	sources map[string][]byte,
+) error {
+
+	fset := token.NewFileSet()
+	for filename, src := range sources {
+		f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+
+		importPath := packageImportPathOf(
+			pkgImportPath,
+			primaryPackage,
+			f.Name.Name,
+		)
+
+		pkg, ok := c.pkgs[importPath]
+		if !ok {
+			pkg = &dependentTypePackage{
+				types: make(map[string]dependentTypeDecl),
+			}
+			c.pkgs[importPath] = pkg
+		}
+
+		err = pkg.addFile(importPath, f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// typeDecl returns the declaration of the type `name` in the package
+// `importPath`, loading the package (from `dir`, see loadPackage) if it has
+// not already been loaded
+//
+// Returns false if the package does not declare `name`.
+func (c *dependentTypeCache) typeDecl(
+	importPath string,
+	name string,
+	dir string,
+) (dependentTypeDecl, bool, error) {
+
+	if importPath == "" {
+		return dependentTypeDecl{}, false, errors.New("package import path cannot be empty for dependent type '" + name + "'")
+	}
+
+	pkg, err := c.loadPackage(importPath, dir)
+	if err != nil {
+		return dependentTypeDecl{}, false, err
+	}
+
+	decl, ok := pkg.types[name]
+	return decl, ok, nil
+}
+
+// loadPackage returns the package `importPath`, loading it if it has not
+// already been loaded
+//
+// The package is resolved from the module containing `dir`, or from the
+// working directory if `dir` is empty.
+func (c *dependentTypeCache) loadPackage(
+	importPath string,
+	dir string,
+) (*dependentTypePackage, error) {
+
+	pkg, ok := c.pkgs[importPath]
+	if !ok {
+		pkg = loadDependentTypePackage(importPath, dir)
+		c.pkgs[importPath] = pkg
+	}
+
+	if pkg.err != nil {
+		return nil, pkg.err
+	}
+	return pkg, nil
+}
+
+func loadDependentTypePackage(importPath string, dir string) *dependentTypePackage {
+
+	conf := &packages.Config{
+		Mode: packages.NeedFiles |
+			packages.NeedName |
+			packages.NeedSyntax,
+		Dir: dir,
+	}
+	pkgs, err := packages.Load(conf, importPath)
+	if err != nil {
+		return &dependentTypePackage{err: err}
+	}
+
+	if len(pkgs) == 0 {
+		return &dependentTypePackage{
+			err: errors.New("package not found '" + importPath + "'"),
+		}
+	}
+
+	if len(pkgs[0].Errors) > 0 {
+		return &dependentTypePackage{err: pkgs[0].Errors[0]}
+	}
+
+	pkg := &dependentTypePackage{
+		types: make(map[string]dependentTypeDecl),
+	}
+	for _, f := range pkgs[0].Syntax {
+		err := pkg.addFile(importPath, f)
+		if err != nil {
+			return &dependentTypePackage{err: err}
+		}
+	}
+	return pkg
+}
+
+// addFile adds the top level type declarations in `f` to the package
+//
+// If a type is declared more than once (e.g. in files for different
+// platforms) then the first declaration is used.
+func (p *dependentTypePackage) addFile(importPath string, f *ast.File) error {
+
+	imports, err := parseImportsFromAstFile(f)
+	if err != nil {
+		return err
+	}
+
+	fileImports := buildFileAliasesAndImports(importPath, imports)
+
+	for _, decl := range f.Decls {
+		d, ok := decl.(*ast.GenDecl)
+		if !ok || d.Tok != token.TYPE {
+			continue
+		}
+
+		for _, declSpec := range d.Specs {
+			s := declSpec.(*ast.TypeSpec)
+			if _, exists := p.types[s.Name.Name]; exists {
+				continue
+			}
+
+			p.types[s.Name.Name] = dependentTypeDecl{
+				spec:        s,
+				fileImports: fileImports,
+			}
+		}
+	}
+	return nil
+}
+
+// getDependentType returns the type declared as `name` in the package
+// `importPath` (i.e. the ValueType of the TypeNamed which refers to it)
+//
+// Types are resolved recursively up to the depth set by ParseDependentTypes
+// (or ParseDependentTypesDepth); a nil type is returned beyond that depth and
+// for a type which is already being resolved (i.e. a recursive type).
+//
+// If `mustExist` is false then a nil type is also returned if the package does
+// not declare `name`.
+func getDependentType(
+	parseOpts parseOptions,
+	importPath string,
+	name string,
+	mustExist bool,
+) (Type, error) {
+
+	cache := parseOpts.dependentTypeCache
+	if parseOpts.dependentTypesDepth == 0 || cache == nil {
+		return nil, nil
+	}
+
+	key := importPath + "." + name
+	if cache.resolving[key] {
+		cache.cuts++
+		return nil, nil
+	}
+
+	completedKey := key
+	if parseOpts.dependentTypesDepth > 0 {
+		completedKey += "@" + strconv.Itoa(parseOpts.dependentTypesDepth)
+	}
+
+	if t, ok := cache.completed[completedKey]; ok {
+		return t, nil
+	}
+
+	decl, ok, err := cache.typeDecl(importPath, name, parseOpts.loadDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get ast for dependent type")
+	}
+
+	if !ok {
+		if !mustExist {
+			return nil, nil
+		}
+		return nil, errors.New("failed to get ast for dependent type: type '" + name + "' not found in packge '" + importPath + "'")
+	}
+
+	nestedOpts := parseOptions{
+		pkgImportPath:       importPath,
+		dependentTypesDepth: parseOpts.dependentTypesDepth - 1,
+		dependentTypeCache:  cache,
+		loadDir:             parseOpts.loadDir,
+	}
+
+	// A negative depth is unlimited
+	if parseOpts.dependentTypesDepth < 0 {
+		nestedOpts.dependentTypesDepth = parseOpts.dependentTypesDepth
+	}
+
+	nestedOpts = withTypeParamsInScope(
+		nestedOpts,
+		typeParamNames(decl.spec.TypeParams)...,
+	)
+
+	cache.resolving[key] = true
+	defer delete(cache.resolving, key)
+
+	cuts := cache.cuts
+	valueType, err := getFullType(nestedOpts, decl.fileImports, decl.spec.Type)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get full type for dependent type")
+	}
+
+	if cache.cuts == cuts {
+		cache.completed[completedKey] = valueType
+	}
+
+	return valueType, nil
+}
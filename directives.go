@@ -0,0 +1,198 @@
+package gopkg
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"go/token"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// directiveRegex matches a comment which is a directive for a tool (e.g.
+// `//go:embed`, `//go:generate` or the cgo `//export`), using the same rules
+// as `go/ast` uses to exclude directives from doc comments
+var directiveRegex = regexp.MustCompile(`^//(line |extern |export |[a-z0-9]+:[a-z0-9])`)
+
+func isDirective(comment string) bool {
+	return directiveRegex.MatchString(comment)
+}
+
+// docAndDirectives splits the comment group `cg` into its doc string and the
+// directives within it
+//
+// Directives are conventionally placed at the end of a doc comment, separated
+// from it by an empty `//` line, which is not included in the doc string.
+// A generated code header (see FileContents.GeneratedBy) is not included in
+// the doc string either.
+func docAndDirectives(
+	src []byte,
+	fileSet *token.FileSet,
+	cg *ast.CommentGroup,
+) (string, []string, error) {
+
+	if cg == nil {
+		return "", nil, nil
+	}
+
+	var directives []string
+	firstDoc, lastDoc := -1, -1
+	for i, c := range cg.List {
+		if isDirective(c.Text) {
+			directives = append(directives, c.Text)
+			continue
+		}
+
+		if isExcludedComment(c.Text) || c.Text == "//" {
+			continue
+		}
+
+		if firstDoc == -1 {
+			firstDoc = i
+		}
+		lastDoc = i
+	}
+
+	if lastDoc == -1 {
+		return "", directives, nil
+	}
+
+	if !hasExcludedComment(cg.List[firstDoc:lastDoc]) {
+		doc, err := readFromFileSet(src, fileSet, cg.List[firstDoc].Pos(), cg.List[lastDoc].End())
+		return doc, directives, err
+	}
+
+	docLines := make([]string, 0, lastDoc-firstDoc+1)
+	for _, c := range cg.List[firstDoc : lastDoc+1] {
+		if !isExcludedComment(c.Text) {
+			docLines = append(docLines, c.Text)
+		}
+	}
+	return strings.Join(docLines, "\n"), directives, nil
+}
+
+// isExcludedComment returns true if `comment` is not part of a doc string;
+// i.e. it is a directive or a generated code header
+func isExcludedComment(comment string) bool {
+
+	if isDirective(comment) {
+		return true
+	}
+	_, isHeader := generatorFromHeader(comment)
+	return isHeader
+}
+
+func hasExcludedComment(comments []*ast.Comment) bool {
+
+	for _, c := range comments {
+		if isExcludedComment(c.Text) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileDirectivesFromAstFile returns the directives within `f` which do not
+// belong to a declaration (e.g. `//go:generate` lines), in the order they
+// appear in the file
+//
+// Build constraints are not included (see FileContents.BuildConstraint).
+func fileDirectivesFromAstFile(f *ast.File) []string {
+
+	declDocs := make(map[*ast.CommentGroup]bool)
+	for _, d := range f.Decls {
+		switch decl := d.(type) {
+		case *ast.FuncDecl:
+			declDocs[decl.Doc] = true
+		case *ast.GenDecl:
+			declDocs[decl.Doc] = true
+		}
+	}
+
+	var directives []string
+	for _, cg := range f.Comments {
+		if declDocs[cg] || isWithinDecl(f, cg) {
+			continue
+		}
+
+		for _, c := range cg.List {
+			if isDirective(c.Text) && !constraint.IsGoBuild(c.Text) {
+				directives = append(directives, c.Text)
+			}
+		}
+	}
+	return directives
+}
+
+func isWithinDecl(f *ast.File, cg *ast.CommentGroup) bool {
+
+	for _, d := range f.Decls {
+		if d.Pos() <= cg.Pos() && cg.End() <= d.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// cgoPreambleFromAstFile returns the comment immediately preceding
+// `import "C"` in `f` (the cgo preamble)
+//
+// Returns an empty string if `f` does not import "C" or there is no preamble.
+func cgoPreambleFromAstFile(
+	src []byte,
+	fileSet *token.FileSet,
+	f *ast.File,
+) (string, error) {
+
+	for _, d := range f.Decls {
+		decl, ok := d.(*ast.GenDecl)
+		if !ok || decl.Tok != token.IMPORT {
+			continue
+		}
+
+		for _, s := range decl.Specs {
+			spec := s.(*ast.ImportSpec)
+			if spec.Path.Value != `"C"` {
+				continue
+			}
+
+			preamble := spec.Doc
+			if preamble == nil && !decl.Lparen.IsValid() {
+				preamble = decl.Doc
+			}
+
+			if preamble == nil {
+				return "", nil
+			}
+
+			return readFromFileSet(src, fileSet, preamble.Pos(), preamble.End())
+		}
+	}
+	return "", nil
+}
+
+// writeDocAndDirectives writes the doc string `doc` followed by
+// `directives`, with each prefixed by `indent`
+func writeDocAndDirectives(
+	w io.Writer,
+	indent string,
+	doc string,
+	directives []string,
+) {
+
+	if doc != "" {
+		w.Write([]byte(indent + doc + "\n"))
+	}
+
+	if len(directives) == 0 {
+		return
+	}
+
+	if doc != "" {
+		w.Write([]byte(indent + "//\n"))
+	}
+
+	for _, d := range directives {
+		w.Write([]byte(indent + d + "\n"))
+	}
+}
@@ -0,0 +1,13 @@
+package enum_stringer
+
+//go:generate go run ../main.go -enum Weekday
+type Weekday int
+
+const (
+	WeekdayUnknown Weekday = iota
+	WeekdayMonday
+	WeekdayTuesday
+	WeekdayWednesday
+	WeekdayThursday
+	WeekdayFriday
+)
@@ -58,6 +58,9 @@ func getPkgNameAndEnumConsts(pkgFiles []gopkg.FileContents, enumName string) (st
 	var pkgName string
 	var enumConsts []string
 	for _, file := range pkgFiles {
+		if file.IsTest {
+			continue
+		}
 		pkgName = file.PackageName
 		for _, constDecl := range file.Consts {
 			if declType, ok := constDecl.Type.(gopkg.TypeNamed); ok {
@@ -86,6 +89,7 @@ func makeStringerImplFile(
 	ret := gopkg.FileContents{
 		PackageName: pkgName,
 		Filepath:    strcase.ToSnake(enumName) + "_string.go",
+		GeneratedBy: "enumstringergen",
 		Functions: []gopkg.DeclFunc{
 			{
 				Name: "String",
@@ -137,6 +141,7 @@ func makeTestFile(
 	ret := gopkg.FileContents{
 		PackageName: pkgName + "_test",
 		Filepath:    strcase.ToSnake(enumName) + "_string_test.go",
+		GeneratedBy: "enumstringergen",
 		Imports: []gopkg.ImportAndAlias{
 			{
 				Import: "github.com/stretchr/testify/require",
@@ -16,6 +16,7 @@ func TestGenerate(t *testing.T) {
 
 	testDirs := []string{
 		"example_single_enum",
+		"example_iota_enum",
 	}
 
 	for _, testDir := range testDirs {
@@ -71,6 +71,7 @@ func makeImplFile(
 	ret := gopkg.FileContents{
 		PackageName: pkgName,
 		Filepath:    strcase.ToSnake(typeName) + "_impl.go",
+		GeneratedBy: "interfacegen",
 	}
 
 	ret.Types = []gopkg.DeclType{
@@ -112,6 +113,7 @@ func makeTestFile(
 	ret := gopkg.FileContents{
 		PackageName: pkgName + "_test",
 		Filepath:    strcase.ToSnake(typeName) + "_impl_test.go",
+		GeneratedBy: "interfacegen",
 		Imports: []gopkg.ImportAndAlias{
 			{
 				Import: pkgImportPath,
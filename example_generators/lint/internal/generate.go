@@ -5,6 +5,8 @@ import (
 	"github.com/thecodedproject/gopkg"
 	//tmpl "github.com/thecodedproject/gopkg/tmpl"
 	"os"
+	"sort"
+	"strings"
 )
 
 var writeInplace = flag.Bool("w", false, "write result to (source) file instead of stdout")
@@ -17,12 +19,30 @@ func Generate() error {
 		return nil
 	}
 
-	// TODO: Allow parsing files recursively with `./...`
 	path := flag.Arg(0)
 
-	pkgFiles, err := gopkg.Parse(path)
-	if err != nil {
-		return err
+	var pkgFiles []gopkg.FileContents
+	if strings.HasSuffix(path, "...") {
+		pkgs, err := gopkg.ParsePackages([]string{path})
+		if err != nil {
+			return err
+		}
+
+		importPaths := make([]string, 0, len(pkgs))
+		for importPath := range pkgs {
+			importPaths = append(importPaths, importPath)
+		}
+		sort.Strings(importPaths)
+
+		for _, importPath := range importPaths {
+			pkgFiles = append(pkgFiles, pkgs[importPath]...)
+		}
+	} else {
+		var err error
+		pkgFiles, err = gopkg.Parse(path)
+		if err != nil {
+			return err
+		}
 	}
 
 	if *writeInplace {
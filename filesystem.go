@@ -1,10 +1,18 @@
 package gopkg
 
 import (
+	"errors"
+	"io/fs"
 	"os"
+	slashpath "path"
 	path "path/filepath"
+	"testing/fstest"
 )
 
+// generatedFileMode is the mode given to files written by writeFileAtomic
+// which do not already exist
+const generatedFileMode = 0o644
+
 // CreatePathAndOpen creats all contains directors in filepath if they do not
 // exist and opens a file for writing at filepath.
 //
@@ -27,3 +35,146 @@ func CreatePathAndOpen(
 
 	return os.Create(filepath)
 }
+
+// writeFileAtomic writes `contents` to filepath, creating any directories in
+// filepath which do not exist.
+//
+// The contents are first written to a temporary file in the same directory
+// which is then renamed to filepath, so filepath is never left partially
+// written. If filepath already exists its file mode is kept.
+func writeFileAtomic(
+	filepath string,
+	contents []byte,
+) error {
+
+	dir, file := path.Split(filepath)
+	if dir == "" {
+		dir = "."
+	}
+
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(generatedFileMode)
+	if info, err := os.Stat(filepath); err == nil {
+		if info.IsDir() {
+			return errors.New("cannot write file '" + filepath + "': is a directory")
+		}
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+file+".tmp*")
+	if err != nil {
+		return err
+	}
+	// Removing the temp file fails harmlessly once it has been renamed
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(contents)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Chmod(mode)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath)
+}
+
+// GenerateFS is a writable filesystem which Generate writes files to (see
+// GenerateToFS)
+type GenerateFS interface {
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+
+	// WriteFile writes `data` to the file `name`, creating any directories
+	// which do not exist and replacing the file if it does
+	WriteFile(name string, data []byte) error
+
+	Remove(name string) error
+}
+
+// OSFS is a GenerateFS which reads and writes files on the OS filesystem
+//
+// Files are written atomically, via a temporary file in the same directory.
+type OSFS struct{}
+
+func (OSFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (OSFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (OSFS) WriteFile(name string, data []byte) error {
+	return writeFileAtomic(name, data)
+}
+
+func (OSFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// MemFS is an in-memory GenerateFS, e.g. for testing generators without any
+// disk IO.
+//
+// MemFS is also an `fs.FS`, so generated files can be read back with Parse
+// (see ParseFromFS). Paths are slash separated and must be valid `fs.FS` paths
+// after cleaning (e.g. `./a/b.go` is the same file as `a/b.go`).
+type MemFS struct {
+	fstest.MapFS
+}
+
+func NewMemFS() *MemFS {
+	return &MemFS{
+		MapFS: make(fstest.MapFS),
+	}
+}
+
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	return m.MapFS.ReadFile(cleanMemFSPath(name))
+}
+
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return m.MapFS.ReadDir(cleanMemFSPath(name))
+}
+
+func (m *MemFS) WriteFile(name string, data []byte) error {
+
+	name = cleanMemFSPath(name)
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+
+	m.MapFS[name] = &fstest.MapFile{
+		Data: append([]byte(nil), data...),
+		Mode: generatedFileMode,
+	}
+	return nil
+}
+
+func (m *MemFS) Remove(name string) error {
+
+	name = cleanMemFSPath(name)
+	if _, ok := m.MapFS[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+
+	delete(m.MapFS, name)
+	return nil
+}
+
+func cleanMemFSPath(name string) string {
+	return slashpath.Clean(name)
+}
@@ -1,22 +1,62 @@
 package gopkg
 
 import (
+	"bytes"
 	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
 )
 
-func Generate(files []FileContents) error {
+// Generate writes each of `files` to its Filepath, creating any directories
+// needed; the output is formatted with gofmt (see WriteFormattedFileContents)
+//
+// All files are rendered before anything is written, so if any file fails to
+// render then no files are changed. Each file is only written if its contents
+// have changed, so unchanged files keep their modification time, and is
+// written atomically (see OSFS).
+//
+// Files are written to the OS filesystem unless another GenerateFS is given
+// with GenerateToFS.
+func Generate(files []FileContents, opts ...GenerateOption) error {
 
-	for _, file := range files {
+	genOpts := buildGenerateOptions(opts)
+	fsys := genOpts.fsys
 
-		if file.Filepath == "" {
-			return errors.New("gopkg.Generate: empty FileContents.Filepath - this is required")
+	rendered, err := renderFiles("gopkg.Generate", files)
+	if err != nil {
+		return err
+	}
+
+	if !genOpts.forceOverwrite {
+		err := checkNotOverwritingHandWrittenFiles(fsys, files)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, file := range files {
+
+		existing, err := fsys.ReadFile(file.Filepath)
+		if err == nil && bytes.Equal(existing, rendered[i]) {
+			continue
 		}
 
-		writer, err := CreatePathAndOpen(file.Filepath)
+		err = fsys.WriteFile(file.Filepath, rendered[i])
 		if err != nil {
 			return err
 		}
-		err = WriteFileContents(writer, file)
+	}
+
+	if genOpts.pruneDir != "" {
+		_, err := pruneGenerated(
+			fsys,
+			genOpts.pruneDir,
+			genOpts.pruneGeneratedBy,
+			files,
+		)
 		if err != nil {
 			return err
 		}
@@ -25,6 +65,136 @@ func Generate(files []FileContents) error {
 	return nil
 }
 
+// PruneGenerated removes any Go files directly within `dir` which have a
+// generated code header from `generatedBy` but are not one of `files`, and
+// returns the paths of the removed files.
+//
+// This removes stale generated files (e.g. for an enum or interface which has
+// since been deleted) which would otherwise be left behind. Files generated by
+// other generators and hand written files are never removed.
+func PruneGenerated(
+	dir string,
+	generatedBy string,
+	files []FileContents,
+) ([]string, error) {
+
+	return pruneGenerated(OSFS{}, dir, generatedBy, files)
+}
+
+func pruneGenerated(
+	fsys GenerateFS,
+	dir string,
+	generatedBy string,
+	files []FileContents,
+) ([]string, error) {
+
+	if generatedBy == "" {
+		return nil, errors.New("gopkg.PruneGenerated: generatedBy cannot be empty")
+	}
+
+	keep := make(map[string]bool)
+	for _, file := range files {
+		absPath, err := filepath.Abs(file.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		keep[absPath] = true
+	}
+
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, entry := range entries {
+
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".go" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if keep[absPath] {
+			continue
+		}
+
+		src, err := fsys.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		fileGeneratedBy, ok := generatorFromSource(src)
+		if !ok || fileGeneratedBy != generatedBy {
+			continue
+		}
+
+		err = fsys.Remove(path)
+		if err != nil {
+			return nil, err
+		}
+		removed = append(removed, path)
+	}
+
+	return removed, nil
+}
+
+type GenerateOption func(generateOptions) generateOptions
+
+type generateOptions struct {
+	fsys GenerateFS
+
+	forceOverwrite bool
+
+	pruneDir         string
+	pruneGeneratedBy string
+}
+
+func buildGenerateOptions(opts []GenerateOption) generateOptions {
+
+	genOpts := generateOptions{
+		fsys: OSFS{},
+	}
+	for _, opt := range opts {
+		genOpts = opt(genOpts)
+	}
+	return genOpts
+}
+
+// GenerateToFS makes Generate (and CheckGenerate) read and write files in
+// `fsys` rather than the OS filesystem (e.g. a MemFS for testing)
+func GenerateToFS(fsys GenerateFS) GenerateOption {
+	return func(o generateOptions) generateOptions {
+		o.fsys = fsys
+		return o
+	}
+}
+
+// GenerateForceOverwrite allows Generate to overwrite existing files which do
+// not have a generated code header with files which set
+// FileContents.GeneratedBy
+func GenerateForceOverwrite() GenerateOption {
+	return func(o generateOptions) generateOptions {
+		o.forceOverwrite = true
+		return o
+	}
+}
+
+// GeneratePruneStale makes Generate remove any files in `dir` which were
+// previously generated by `generatedBy` but which are not in the files being
+// generated (see PruneGenerated)
+func GeneratePruneStale(dir string, generatedBy string) GenerateOption {
+	return func(o generateOptions) generateOptions {
+		o.pruneDir = dir
+		o.pruneGeneratedBy = generatedBy
+		return o
+	}
+}
+
 func LintAndGenerate(
 	files []FileContents,
 	extraLintRules ...func([]FileContents) error,
@@ -42,3 +212,149 @@ func LintAndGenerate(
 
 	return nil
 }
+
+// GenerateCheckResult is the result of comparing the files which Generate
+// would write with the files currently on disk (see CheckGenerate)
+type GenerateCheckResult struct {
+	// New holds the paths of files which do not exist on disk
+	New []string
+
+	// Changed holds the paths of files which exist on disk but whose contents
+	// differ from the generated contents
+	Changed []string
+
+	// Unchanged holds the paths of files whose contents on disk are the same
+	// as the generated contents
+	Unchanged []string
+
+	// Diffs holds a unified diff, from the contents on disk to the generated
+	// contents, for each of the Changed files (keyed by file path)
+	Diffs map[string]string
+}
+
+// UpToDate returns true if running Generate would not create or change any
+// files
+func (r GenerateCheckResult) UpToDate() bool {
+	return len(r.New) == 0 && len(r.Changed) == 0
+}
+
+// CheckGenerate renders each of `files` in the same way as Generate and
+// compares them with the files on disk, without writing anything.
+//
+// This can be used (e.g. in CI) to detect generated code which is stale
+// because a generator has changed but has not been re-run.
+//
+// Of the GenerateOptions, only GenerateToFS affects CheckGenerate.
+func CheckGenerate(
+	files []FileContents,
+	opts ...GenerateOption,
+) (GenerateCheckResult, error) {
+
+	fsys := buildGenerateOptions(opts).fsys
+
+	rendered, err := renderFiles("gopkg.CheckGenerate", files)
+	if err != nil {
+		return GenerateCheckResult{}, err
+	}
+
+	var ret GenerateCheckResult
+	for i, file := range files {
+
+		existing, err := fsys.ReadFile(file.Filepath)
+		if errors.Is(err, fs.ErrNotExist) {
+			ret.New = append(ret.New, file.Filepath)
+			continue
+		} else if err != nil {
+			return GenerateCheckResult{}, err
+		}
+
+		if bytes.Equal(existing, rendered[i]) {
+			ret.Unchanged = append(ret.Unchanged, file.Filepath)
+			continue
+		}
+
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(string(existing)),
+			B:        splitLines(string(rendered[i])),
+			FromFile: file.Filepath,
+			ToFile:   file.Filepath + " (generated)",
+			Context:  3,
+		})
+		if err != nil {
+			return GenerateCheckResult{}, err
+		}
+
+		if ret.Diffs == nil {
+			ret.Diffs = make(map[string]string)
+		}
+		ret.Changed = append(ret.Changed, file.Filepath)
+		ret.Diffs[file.Filepath] = diff
+	}
+
+	return ret, nil
+}
+
+// checkNotOverwritingHandWrittenFiles returns an error if any of `files` which
+// are marked as generated would overwrite an existing file which does not
+// have a generated code header (i.e. a hand written file)
+func checkNotOverwritingHandWrittenFiles(
+	fsys GenerateFS,
+	files []FileContents,
+) error {
+
+	for _, file := range files {
+
+		if file.GeneratedBy == "" {
+			continue
+		}
+
+		existing, err := fsys.ReadFile(file.Filepath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if !hasGeneratedHeader(existing) {
+			return errors.New(
+				"gopkg.Generate: refusing to overwrite '" + file.Filepath +
+					"' which is not a generated file (use GenerateForceOverwrite to overwrite it)",
+			)
+		}
+	}
+
+	return nil
+}
+
+// renderFiles renders each of `files` with WriteFormattedFileContents and
+// returns the contents of each, in the same order as `files`
+func renderFiles(caller string, files []FileContents) ([][]byte, error) {
+
+	rendered := make([][]byte, 0, len(files))
+	for _, file := range files {
+
+		if file.Filepath == "" {
+			return nil, errors.New(caller + ": empty FileContents.Filepath - this is required")
+		}
+
+		buffer := bytes.NewBuffer(nil)
+		err := WriteFormattedFileContents(buffer, file)
+		if err != nil {
+			return nil, err
+		}
+
+		rendered = append(rendered, buffer.Bytes())
+	}
+
+	return rendered, nil
+}
+
+// splitLines splits `s` into lines, keeping the trailing newline on each line
+func splitLines(s string) []string {
+
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
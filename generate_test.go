@@ -0,0 +1,497 @@
+package gopkg_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thecodedproject/gopkg"
+)
+
+func TestGenerate(t *testing.T) {
+
+	t.Run("empty filepath returns error", func(t *testing.T) {
+		err := gopkg.Generate([]gopkg.FileContents{
+			{PackageName: "mypkg"},
+		})
+		require.Equal(
+			t,
+			errors.New("gopkg.Generate: empty FileContents.Filepath - this is required"),
+			err,
+		)
+	})
+
+	t.Run("writes files creating directories and leaves no temp files", func(t *testing.T) {
+
+		dir := t.TempDir()
+
+		err := gopkg.Generate([]gopkg.FileContents{
+			{
+				Filepath:    filepath.Join(dir, "a.go"),
+				PackageName: "mypkg",
+			},
+			{
+				Filepath:    filepath.Join(dir, "sub", "b.go"),
+				PackageName: "sub",
+			},
+		})
+		require.NoError(t, err)
+
+		a, err := os.ReadFile(filepath.Join(dir, "a.go"))
+		require.NoError(t, err)
+		require.Equal(t, "package mypkg\n", string(a))
+
+		b, err := os.ReadFile(filepath.Join(dir, "sub", "b.go"))
+		require.NoError(t, err)
+		require.Equal(t, "package sub\n", string(b))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+	})
+
+	t.Run("does not write any files if one fails to render", func(t *testing.T) {
+
+		dir := t.TempDir()
+		existingPath := filepath.Join(dir, "existing.go")
+
+		err := os.WriteFile(existingPath, []byte("package existing\n"), 0o644)
+		require.NoError(t, err)
+
+		err = gopkg.Generate([]gopkg.FileContents{
+			{
+				Filepath:    existingPath,
+				PackageName: "changed",
+			},
+			{
+				Filepath:    filepath.Join(dir, "broken.go"),
+				PackageName: "broken",
+				Functions: []gopkg.DeclFunc{
+					{
+						Name:     "Broken",
+						BodyTmpl: "\n\treturn (\n",
+					},
+				},
+			},
+		})
+		require.Error(t, err)
+
+		existing, err := os.ReadFile(existingPath)
+		require.NoError(t, err)
+		require.Equal(t, "package existing\n", string(existing))
+
+		_, err = os.Stat(filepath.Join(dir, "broken.go"))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("unchanged files are not rewritten", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "a.go")
+		files := []gopkg.FileContents{
+			{
+				Filepath:    path,
+				PackageName: "mypkg",
+			},
+		}
+
+		err := gopkg.Generate(files)
+		require.NoError(t, err)
+
+		past := time.Now().Add(-time.Hour).Truncate(time.Second)
+		err = os.Chtimes(path, past, past)
+		require.NoError(t, err)
+
+		err = gopkg.Generate(files)
+		require.NoError(t, err)
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.True(t, past.Equal(info.ModTime()))
+	})
+
+	t.Run("refuses to overwrite hand written file with generated file", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "a.go")
+		err := os.WriteFile(path, []byte("package handwritten\n"), 0o644)
+		require.NoError(t, err)
+
+		err = gopkg.Generate([]gopkg.FileContents{
+			{
+				Filepath:    path,
+				PackageName: "mypkg",
+				GeneratedBy: "mygenerator",
+			},
+		})
+		require.Equal(
+			t,
+			errors.New("gopkg.Generate: refusing to overwrite '"+path+"' which is not a generated file (use GenerateForceOverwrite to overwrite it)"),
+			err,
+		)
+
+		existing, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "package handwritten\n", string(existing))
+	})
+
+	t.Run("overwrites hand written file when forced", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "a.go")
+		err := os.WriteFile(path, []byte("package handwritten\n"), 0o644)
+		require.NoError(t, err)
+
+		err = gopkg.Generate(
+			[]gopkg.FileContents{
+				{
+					Filepath:    path,
+					PackageName: "mypkg",
+					GeneratedBy: "mygenerator",
+				},
+			},
+			gopkg.GenerateForceOverwrite(),
+		)
+		require.NoError(t, err)
+
+		existing, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "// Code generated by mygenerator. DO NOT EDIT.\n\npackage mypkg\n", string(existing))
+	})
+
+	t.Run("overwrites file generated by another generator", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "a.go")
+		err := os.WriteFile(path, []byte("// Code generated by othergen. DO NOT EDIT.\n\npackage old\n"), 0o644)
+		require.NoError(t, err)
+
+		err = gopkg.Generate([]gopkg.FileContents{
+			{
+				Filepath:    path,
+				PackageName: "mypkg",
+				GeneratedBy: "mygenerator",
+			},
+		})
+		require.NoError(t, err)
+
+		existing, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "// Code generated by mygenerator. DO NOT EDIT.\n\npackage mypkg\n", string(existing))
+	})
+}
+
+func TestGenerateToFS(t *testing.T) {
+
+	t.Run("writes files to in memory filesystem without disk io", func(t *testing.T) {
+
+		memFS := gopkg.NewMemFS()
+
+		err := gopkg.Generate(
+			[]gopkg.FileContents{
+				{
+					Filepath:    "memfs_only/a.go",
+					PackageName: "mypkg",
+					GeneratedBy: "mygen",
+				},
+			},
+			gopkg.GenerateToFS(memFS),
+		)
+		require.NoError(t, err)
+
+		contents, err := memFS.ReadFile("memfs_only/a.go")
+		require.NoError(t, err)
+		require.Equal(t, "// Code generated by mygen. DO NOT EDIT.\n\npackage mypkg\n", string(contents))
+
+		_, err = os.Stat("memfs_only")
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("generated files can be parsed from in memory filesystem", func(t *testing.T) {
+
+		memFS := gopkg.NewMemFS()
+
+		err := gopkg.Generate(
+			[]gopkg.FileContents{
+				{
+					Filepath:    "./mypkg/a.go",
+					PackageName: "mypkg",
+					Vars: []gopkg.DeclVar{
+						{Name: "A", Type: gopkg.TypeInt{}},
+					},
+				},
+			},
+			gopkg.GenerateToFS(memFS),
+		)
+		require.NoError(t, err)
+
+		pkg, err := gopkg.Parse(
+			"mypkg",
+			gopkg.ParseFromFS(memFS),
+			gopkg.ParseWithPkgImportPath("some/mypkg"),
+		)
+		require.NoError(t, err)
+
+		require.Equal(
+			t,
+			[]gopkg.FileContents{
+				{
+					Filepath:          "mypkg/a.go",
+					PackageName:       "mypkg",
+					PackageImportPath: "some/mypkg",
+					Vars: []gopkg.DeclVar{
+						{
+							Name:   "A",
+							Import: "some/mypkg",
+							Type:   gopkg.TypeInt{},
+						},
+					},
+				},
+			},
+			pkg,
+		)
+	})
+
+	t.Run("check and prune use in memory filesystem", func(t *testing.T) {
+
+		memFS := gopkg.NewMemFS()
+		err := memFS.WriteFile("out/stale.go", []byte("// Code generated by mygen. DO NOT EDIT.\n\npackage out\n"))
+		require.NoError(t, err)
+
+		files := []gopkg.FileContents{
+			{
+				Filepath:    "out/a.go",
+				PackageName: "out",
+				GeneratedBy: "mygen",
+			},
+		}
+
+		res, err := gopkg.CheckGenerate(files, gopkg.GenerateToFS(memFS))
+		require.NoError(t, err)
+		require.Equal(t, []string{"out/a.go"}, res.New)
+
+		err = gopkg.Generate(
+			files,
+			gopkg.GenerateToFS(memFS),
+			gopkg.GeneratePruneStale("out", "mygen"),
+		)
+		require.NoError(t, err)
+
+		_, err = memFS.ReadFile("out/stale.go")
+		require.True(t, errors.Is(err, fs.ErrNotExist))
+
+		res, err = gopkg.CheckGenerate(files, gopkg.GenerateToFS(memFS))
+		require.NoError(t, err)
+		require.True(t, res.UpToDate())
+	})
+}
+
+func TestMemFS(t *testing.T) {
+
+	memFS := gopkg.NewMemFS()
+
+	err := memFS.WriteFile("../outside.go", nil)
+	require.Error(t, err)
+
+	err = memFS.Remove("missing.go")
+	require.True(t, errors.Is(err, fs.ErrNotExist))
+
+	err = memFS.WriteFile("a/b.go", []byte("b"))
+	require.NoError(t, err)
+
+	entries, err := memFS.ReadDir("a")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "b.go", entries[0].Name())
+
+	err = memFS.Remove("./a/b.go")
+	require.NoError(t, err)
+
+	_, err = memFS.ReadFile("a/b.go")
+	require.True(t, errors.Is(err, fs.ErrNotExist))
+}
+
+func TestCheckGenerate(t *testing.T) {
+
+	t.Run("empty filepath returns error", func(t *testing.T) {
+		_, err := gopkg.CheckGenerate([]gopkg.FileContents{
+			{PackageName: "mypkg"},
+		})
+		require.Equal(
+			t,
+			errors.New("gopkg.CheckGenerate: empty FileContents.Filepath - this is required"),
+			err,
+		)
+	})
+
+	t.Run("reports new, changed and unchanged files without writing", func(t *testing.T) {
+
+		dir := t.TempDir()
+
+		unchanged := gopkg.FileContents{
+			Filepath:    filepath.Join(dir, "unchanged.go"),
+			PackageName: "mypkg",
+			Vars: []gopkg.DeclVar{
+				{Name: "A", Type: gopkg.TypeInt{}},
+			},
+		}
+		changed := gopkg.FileContents{
+			Filepath:    filepath.Join(dir, "changed.go"),
+			PackageName: "mypkg",
+			Vars: []gopkg.DeclVar{
+				{Name: "B", Type: gopkg.TypeInt{}},
+			},
+		}
+		newFile := gopkg.FileContents{
+			Filepath:    filepath.Join(dir, "sub", "new.go"),
+			PackageName: "mypkg",
+		}
+
+		err := gopkg.Generate([]gopkg.FileContents{unchanged, changed})
+		require.NoError(t, err)
+
+		changed.Vars[0].Type = gopkg.TypeString{}
+
+		res, err := gopkg.CheckGenerate([]gopkg.FileContents{
+			unchanged,
+			changed,
+			newFile,
+		})
+		require.NoError(t, err)
+
+		require.False(t, res.UpToDate())
+		require.Equal(t, []string{newFile.Filepath}, res.New)
+		require.Equal(t, []string{changed.Filepath}, res.Changed)
+		require.Equal(t, []string{unchanged.Filepath}, res.Unchanged)
+
+		expectedDiff := "--- " + changed.Filepath + "\n" +
+			"+++ " + changed.Filepath + " (generated)\n" +
+			"@@ -1,3 +1,3 @@\n" +
+			" package mypkg\n" +
+			" \n" +
+			"-var B int\n" +
+			"+var B string\n"
+		require.Equal(t, map[string]string{changed.Filepath: expectedDiff}, res.Diffs)
+
+		_, err = os.Stat(newFile.Filepath)
+		require.True(t, os.IsNotExist(err))
+
+		existing, err := os.ReadFile(changed.Filepath)
+		require.NoError(t, err)
+		require.Equal(t, "package mypkg\n\nvar B int\n", string(existing))
+	})
+
+	t.Run("all files unchanged is up to date", func(t *testing.T) {
+
+		files := []gopkg.FileContents{
+			{
+				Filepath:    filepath.Join(t.TempDir(), "a.go"),
+				PackageName: "mypkg",
+			},
+		}
+
+		err := gopkg.Generate(files)
+		require.NoError(t, err)
+
+		res, err := gopkg.CheckGenerate(files)
+		require.NoError(t, err)
+
+		require.True(t, res.UpToDate())
+		require.Equal(t, []string{files[0].Filepath}, res.Unchanged)
+		require.Nil(t, res.Diffs)
+	})
+}
+
+func TestPruneGenerated(t *testing.T) {
+
+	t.Run("empty generated by returns error", func(t *testing.T) {
+		_, err := gopkg.PruneGenerated(t.TempDir(), "", nil)
+		require.Equal(
+			t,
+			errors.New("gopkg.PruneGenerated: generatedBy cannot be empty"),
+			err,
+		)
+	})
+
+	t.Run("removes only stale files from the same generator", func(t *testing.T) {
+
+		dir := t.TempDir()
+
+		writeTestFiles(t, dir, map[string]string{
+			"kept.go":      "// Code generated by mygen. DO NOT EDIT.\n\npackage a\n",
+			"stale.go":     "// Code generated by mygen. DO NOT EDIT.\n\npackage a\n",
+			"other_gen.go": "// Code generated by othergen. DO NOT EDIT.\n\npackage a\n",
+			"hand.go":      "package a\n",
+			"late.go":      "package a\n\n// Code generated by mygen. DO NOT EDIT.\n",
+			"stale.txt":    "// Code generated by mygen. DO NOT EDIT.\n",
+			"sub/stale.go": "// Code generated by mygen. DO NOT EDIT.\n\npackage sub\n",
+		})
+
+		removed, err := gopkg.PruneGenerated(
+			dir,
+			"mygen",
+			[]gopkg.FileContents{
+				{Filepath: filepath.Join(dir, "kept.go")},
+			},
+		)
+		require.NoError(t, err)
+		require.Equal(t, []string{filepath.Join(dir, "stale.go")}, removed)
+
+		requireFilesExist(
+			t,
+			dir,
+			"kept.go",
+			"other_gen.go",
+			"hand.go",
+			"late.go",
+			"stale.txt",
+			"sub/stale.go",
+		)
+	})
+
+	t.Run("generate with prune stale option removes stale files", func(t *testing.T) {
+
+		dir := t.TempDir()
+
+		writeTestFiles(t, dir, map[string]string{
+			"stale.go": "// Code generated by mygen. DO NOT EDIT.\n\npackage a\n",
+		})
+
+		err := gopkg.Generate(
+			[]gopkg.FileContents{
+				{
+					Filepath:    filepath.Join(dir, "new.go"),
+					PackageName: "a",
+					GeneratedBy: "mygen",
+				},
+			},
+			gopkg.GeneratePruneStale(dir, "mygen"),
+		)
+		require.NoError(t, err)
+
+		requireFilesExist(t, dir, "new.go")
+
+		_, err = os.Stat(filepath.Join(dir, "stale.go"))
+		require.True(t, os.IsNotExist(err))
+	})
+}
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+
+	for path, contents := range files {
+		fullPath := filepath.Join(dir, path)
+		err := os.MkdirAll(filepath.Dir(fullPath), os.ModePerm)
+		require.NoError(t, err)
+		err = os.WriteFile(fullPath, []byte(contents), 0o644)
+		require.NoError(t, err)
+	}
+}
+
+func requireFilesExist(t *testing.T, dir string, paths ...string) {
+
+	for _, path := range paths {
+		_, err := os.Stat(filepath.Join(dir, path))
+		require.NoError(t, err, path)
+	}
+}
@@ -0,0 +1,70 @@
+package gopkg
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// generatedHeaderRegex matches the generated code header defined by
+// https://golang.org/s/generatedcode (i.e. `^// Code generated .* DO NOT
+// EDIT\.$`), capturing the text between `generated` and `DO NOT EDIT`
+var generatedHeaderRegex = regexp.MustCompile(`^// Code generated (.*) DO NOT EDIT\.$`)
+
+// generatedHeader returns the generated code header for a file generated by
+// `generatedBy`
+func generatedHeader(generatedBy string) string {
+	return "// Code generated by " + generatedBy + ". DO NOT EDIT."
+}
+
+// generatorFromHeader returns the name of the generator from a generated code
+// header line (e.g. `protoc-gen-go` from
+// `// Code generated by protoc-gen-go. DO NOT EDIT.`)
+//
+// Returns false if `line` is not a generated code header.
+func generatorFromHeader(line string) (string, bool) {
+
+	match := generatedHeaderRegex.FindStringSubmatch(line)
+	if match == nil {
+		return "", false
+	}
+
+	// The generator is conventionally followed by a separator before
+	// `DO NOT EDIT`; e.g. `by protoc-gen-go.` or `by "stringer -type=Pill";`
+	generatedBy := strings.TrimPrefix(match[1], "by ")
+	if strings.HasSuffix(generatedBy, ".") || strings.HasSuffix(generatedBy, ";") ||
+		strings.HasSuffix(generatedBy, ",") {
+
+		generatedBy = generatedBy[:len(generatedBy)-1]
+	}
+	return generatedBy, true
+}
+
+// hasGeneratedHeader returns true if `src` contains a generated code header
+// before its package clause
+func hasGeneratedHeader(src []byte) bool {
+
+	_, ok := generatorFromSource(src)
+	return ok
+}
+
+// generatorFromSource returns the name of the generator from the generated
+// code header of `src`
+//
+// Returns false if `src` has no generated code header before its package
+// clause.
+func generatorFromSource(src []byte) (string, bool) {
+
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "package ") {
+			return "", false
+		}
+		if generatedBy, ok := generatorFromHeader(line); ok {
+			return generatedBy, true
+		}
+	}
+	return "", false
+}
@@ -5,6 +5,7 @@ go 1.19
 require (
 	github.com/iancoleman/strcase v0.2.0
 	github.com/pkg/errors v0.8.1
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/sebdah/goldie/v2 v2.5.3
 	github.com/stretchr/testify v1.3.0
 )
@@ -14,7 +15,6 @@ require (
 	github.com/deckarep/golang-set v1.8.0 // indirect
 	github.com/deckarep/golang-set/v2 v2.1.0 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/sergi/go-diff v1.0.0 // indirect
 	github.com/shopspring/decimal v1.3.1 // indirect
 	golang.org/x/mod v0.14.0 // indirect
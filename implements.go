@@ -0,0 +1,282 @@
+package gopkg
+
+import (
+	"reflect"
+)
+
+// MethodMismatch is a method of an interface which a type does not implement
+//
+// It may instead be an embedded interface of the interface whose methods are
+// unknown, in which case Embed is set and Want is empty.
+type MethodMismatch struct {
+	// Want is the method of the interface
+	Want DeclFunc
+
+	// Embed is the embedded named type of the interface whose methods are
+	// unknown (i.e. its underlying type is unknown)
+	Embed Type
+
+	// Have is the method of the type with the same name as Want, if the type
+	// has one; otherwise its Name is empty
+	Have DeclFunc
+
+	// Reason describes why the type does not implement Want; e.g.
+	// `missing method Close`
+	Reason string
+}
+
+// Implements reports whether `t`, a type declared in the parsed package
+// `files` (e.g. as returned by Parse), implements the interface `iface`
+//
+// If `pointer` is true then whether `*t` implements the interface is reported
+// instead.
+//
+// The methods of `iface` include those of its embedded interfaces. Each must
+// be in the method set of `t` (or `*t`, see MethodSets) with an identical
+// signature; i.e. with identical arg and return types (ignoring their names)
+// and the same VariadicLastArg. Otherwise it is returned as a MethodMismatch,
+// in order of method name. Embedded named types are resolved as for
+// MethodSets; an embedded interface whose methods are unknown cannot be
+// satisfied, and is returned as a MethodMismatch after the methods. The type
+// set of a constraint interface (e.g. a TypeUnion) is not checked.
+func Implements(
+	files []FileContents,
+	t DeclType,
+	iface TypeInterface,
+	pointer bool,
+) (bool, []MethodMismatch) {
+
+	b := newMethodSetBuilder(files)
+
+	value, pointerMethods := MethodSets(files, t)
+
+	methods := value
+	if pointer {
+		methods = pointerMethods
+	}
+
+	have := make(map[string]DeclFunc)
+	for _, m := range methods {
+		have[m.Name] = m
+	}
+
+	// Methods only in the pointer method set are reported as having a
+	// pointer receiver, rather than as missing
+	pointerOnly := make(map[string]DeclFunc)
+	if !pointer {
+		for _, m := range pointerMethods {
+			if _, ok := have[m.Name]; !ok {
+				pointerOnly[m.Name] = m
+			}
+		}
+	}
+
+	wantMethods, unknownEmbeds := b.interfaceMethodsAndUnknownEmbeds(
+		iface,
+		make(map[string]bool),
+	)
+
+	var mismatches []MethodMismatch
+	for _, want := range wantMethods {
+
+		m, ok := have[want.Name]
+		if !ok {
+			if m, ok := pointerOnly[want.Name]; ok {
+				mismatches = append(mismatches, MethodMismatch{
+					Want:   want,
+					Have:   m,
+					Reason: "method " + want.Name + " has pointer receiver",
+				})
+				continue
+			}
+
+			mismatches = append(mismatches, MethodMismatch{
+				Want:   want,
+				Reason: "missing method " + want.Name,
+			})
+			continue
+		}
+
+		if !b.signaturesIdentical(m, want) {
+			mismatches = append(mismatches, MethodMismatch{
+				Want: want,
+				Have: m,
+				Reason: "wrong type for method " + want.Name +
+					": have " + signatureString(m) +
+					", want " + signatureString(want),
+			})
+		}
+	}
+
+	for _, embed := range unknownEmbeds {
+		name, _ := namedTypeKey(embed)
+		mismatches = append(mismatches, MethodMismatch{
+			Embed:  embed,
+			Reason: "unknown methods of embedded " + name,
+		})
+	}
+
+	return len(mismatches) == 0, mismatches
+}
+
+// signaturesIdentical returns true if the args and return args of `x` and `y`
+// have identical types
+func (b methodSetBuilder) signaturesIdentical(x DeclFunc, y DeclFunc) bool {
+
+	return x.VariadicLastArg == y.VariadicLastArg &&
+		b.declVarTypesIdentical(x.Args, y.Args) &&
+		b.declVarTypesIdentical(x.ReturnArgs, y.ReturnArgs)
+}
+
+func (b methodSetBuilder) declVarTypesIdentical(x []DeclVar, y []DeclVar) bool {
+
+	if len(x) != len(y) {
+		return false
+	}
+
+	for i := range x {
+		if !b.typesIdentical(x[i].Type, y[i].Type) {
+			return false
+		}
+	}
+	return true
+}
+
+// typesIdentical returns true if `x` and `y` are the same type
+//
+// Named types are compared by name and import path only (i.e. their
+// ValueType and Methods are ignored), after any declared aliases have been
+// followed.
+func (b methodSetBuilder) typesIdentical(x Type, y Type) bool {
+
+	x = identicalTypeOf(b.followAliases(x))
+	y = identicalTypeOf(b.followAliases(y))
+
+	switch x := x.(type) {
+	case TypeNamed:
+		y, ok := y.(TypeNamed)
+		return ok && x.Name == y.Name && x.Import == y.Import
+
+	case TypeGeneric:
+		y, ok := y.(TypeGeneric)
+		if !ok || x.Name != y.Name || x.Import != y.Import ||
+			len(x.TypeArgs) != len(y.TypeArgs) {
+
+			return false
+		}
+
+		for i := range x.TypeArgs {
+			if !b.typesIdentical(x.TypeArgs[i], y.TypeArgs[i]) {
+				return false
+			}
+		}
+		return true
+
+	case TypePointer:
+		y, ok := y.(TypePointer)
+		return ok && b.typesIdentical(x.ValueType, y.ValueType)
+
+	case TypeArray:
+		y, ok := y.(TypeArray)
+		return ok && b.typesIdentical(x.ValueType, y.ValueType)
+
+	case TypeFixedArray:
+		y, ok := y.(TypeFixedArray)
+		return ok && x.Len == y.Len && b.typesIdentical(x.ValueType, y.ValueType)
+
+	case TypeChan:
+		y, ok := y.(TypeChan)
+		return ok && x.Dir == y.Dir && b.typesIdentical(x.ValueType, y.ValueType)
+
+	case TypeMap:
+		y, ok := y.(TypeMap)
+		return ok && b.typesIdentical(x.KeyType, y.KeyType) &&
+			b.typesIdentical(x.ValueType, y.ValueType)
+
+	case TypeFunc:
+		y, ok := y.(TypeFunc)
+		return ok && x.VariadicLastArg == y.VariadicLastArg &&
+			b.declVarTypesIdentical(x.Args, y.Args) &&
+			b.declVarTypesIdentical(x.ReturnArgs, y.ReturnArgs)
+
+	case TypeStruct:
+		y, ok := y.(TypeStruct)
+		if !ok || len(x.Fields) != len(y.Fields) || len(x.Embeds) != len(y.Embeds) {
+			return false
+		}
+
+		for i := range x.Fields {
+			if x.Fields[i].Name != y.Fields[i].Name ||
+				x.Fields[i].StructTag != y.Fields[i].StructTag ||
+				!b.typesIdentical(x.Fields[i].Type, y.Fields[i].Type) {
+
+				return false
+			}
+		}
+
+		for i := range x.Embeds {
+			if !b.typesIdentical(x.Embeds[i].Type, y.Embeds[i].Type) {
+				return false
+			}
+		}
+		return true
+
+	case TypeInterface:
+		if _, ok := y.(TypeInterface); !ok {
+			return false
+		}
+
+		xMethods, xUnknown := b.interfaceMethodsAndUnknownEmbeds(x, make(map[string]bool))
+		yMethods, yUnknown := b.interfaceMethodsAndUnknownEmbeds(y, make(map[string]bool))
+		if len(xMethods) != len(yMethods) || len(xUnknown) != len(yUnknown) {
+			return false
+		}
+
+		for i := range xUnknown {
+			if !b.typesIdentical(xUnknown[i], yUnknown[i]) {
+				return false
+			}
+		}
+
+		for i := range xMethods {
+			if xMethods[i].Name != yMethods[i].Name ||
+				!b.signaturesIdentical(xMethods[i], yMethods[i]) {
+
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(x, y)
+}
+
+// identicalTypeOf returns the type which `t` is identical to for the
+// predeclared types which have more than one name; e.g. `byte` is `uint8`
+func identicalTypeOf(t Type) Type {
+
+	switch t.(type) {
+	case TypeAny:
+		return TypeInterface{}
+	case TypeByte:
+		return TypeUint8{}
+	case TypeRune:
+		return TypeInt32{}
+	}
+	return t
+}
+
+// signatureString returns the signature of `f` as a func type, for use in
+// error messages
+func signatureString(f DeclFunc) string {
+
+	s, err := TypeFunc{
+		Args:            f.Args,
+		VariadicLastArg: f.VariadicLastArg,
+		ReturnArgs:      f.ReturnArgs,
+	}.FullType(nil)
+	if err != nil {
+		return f.Name
+	}
+	return s
+}
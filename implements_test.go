@@ -0,0 +1,165 @@
+package gopkg_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thecodedproject/gopkg"
+	"github.com/thecodedproject/gopkg/tmpl"
+)
+
+func TestImplements(t *testing.T) {
+
+	pkg, err := gopkg.Parse(
+		"test_packages/method_sets",
+		gopkg.ParseWithPkgImportPath("some/import/method_sets"),
+	)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		Name            string
+		TypeName        string
+		Interface       gopkg.TypeInterface
+		Pointer         bool
+		Expected        bool
+		ExpectedReasons []string
+	}{
+		{
+			Name:      "empty interface is implemented",
+			TypeName:  "Named",
+			Interface: gopkg.TypeInterface{},
+			Expected:  true,
+		},
+		{
+			Name:     "embedded interfaces are implemented by promoted methods",
+			TypeName: "Service",
+			Interface: gopkg.TypeInterface{
+				Embeds: []gopkg.Type{
+					gopkg.TypeNamed{
+						Name:   "Logger",
+						Import: "some/import/method_sets",
+					},
+				},
+				Funcs: []gopkg.DeclFunc{
+					{
+						Name:       "Count",
+						ReturnArgs: tmpl.UnnamedReturnArgs(gopkg.TypeInt{}),
+					},
+				},
+			},
+			Expected: true,
+		},
+		{
+			Name:     "value does not implement method with pointer receiver",
+			TypeName: "Service",
+			Interface: gopkg.TypeInterface{
+				Funcs: []gopkg.DeclFunc{
+					{
+						Name:       "Start",
+						ReturnArgs: tmpl.UnnamedReturnArgs(gopkg.TypeError{}),
+					},
+				},
+			},
+			ExpectedReasons: []string{
+				"method Start has pointer receiver",
+			},
+		},
+		{
+			Name:     "pointer implements method with pointer receiver",
+			TypeName: "Service",
+			Interface: gopkg.TypeInterface{
+				Funcs: []gopkg.DeclFunc{
+					{
+						Name:       "Start",
+						ReturnArgs: tmpl.UnnamedReturnArgs(gopkg.TypeError{}),
+					},
+				},
+			},
+			Pointer:  true,
+			Expected: true,
+		},
+		{
+			Name:     "missing and mismatched methods are returned in name order",
+			TypeName: "Service",
+			Interface: gopkg.TypeInterface{
+				Funcs: []gopkg.DeclFunc{
+					{
+						Name: "Stop",
+					},
+					{
+						Name:       "Count",
+						ReturnArgs: tmpl.UnnamedReturnArgs(gopkg.TypeInt64{}),
+					},
+					{
+						Name: "Log",
+						Args: []gopkg.DeclVar{
+							{Name: "msg", Type: gopkg.TypeString{}},
+							{Name: "args", Type: gopkg.TypeArray{ValueType: gopkg.TypeAny{}}},
+						},
+					},
+				},
+			},
+			Pointer: true,
+			ExpectedReasons: []string{
+				"wrong type for method Count: have func() int, want func() int64",
+				"wrong type for method Log: have func(msg string, args ...any), want func(msg string, args []any)",
+				"missing method Stop",
+			},
+		},
+		{
+			Name:     "embedded interface with unknown methods is not implemented",
+			TypeName: "Named",
+			Interface: gopkg.TypeInterface{
+				Embeds: []gopkg.Type{
+					gopkg.TypeNamed{
+						Name:   "Reader",
+						Import: "io",
+					},
+				},
+			},
+			ExpectedReasons: []string{
+				"unknown methods of embedded io.Reader",
+			},
+		},
+		{
+			Name:     "arg names are ignored and identical predeclared types match",
+			TypeName: "Service",
+			Interface: gopkg.TypeInterface{
+				Funcs: []gopkg.DeclFunc{
+					{
+						Name: "Log",
+						Args: []gopkg.DeclVar{
+							{Name: "message", Type: gopkg.TypeString{}},
+							{Name: "values", Type: gopkg.TypeInterface{}},
+						},
+						VariadicLastArg: true,
+					},
+				},
+			},
+			Expected: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			declType := findDeclType(t, pkg, test.TypeName)
+
+			implements, mismatches := gopkg.Implements(
+				pkg,
+				declType,
+				test.Interface,
+				test.Pointer,
+			)
+
+			require.Equal(t, test.Expected, implements)
+
+			var reasons []string
+			for _, m := range mismatches {
+				reasons = append(reasons, m.Reason)
+			}
+			require.Equal(t, test.ExpectedReasons, reasons)
+		})
+	}
+}
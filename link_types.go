@@ -0,0 +1,281 @@
+package gopkg
+
+// linkLocalTypes sets the ValueType of every TypeNamed (and TypeGeneric)
+// within `files` which refers to a type declared in `files`, to the type
+// given in its declaration
+//
+// Named types within a linked ValueType are linked in the same way, except
+// where a type refers to itself (directly or through other types) - such a
+// reference is left with a nil ValueType, so that recursive types terminate.
+// A ValueType which has already been set (e.g. by ParseDependentTypes) is not
+// changed.
+//
+// The types in `declTypes` (e.g. those declared in the other files of the
+// package of a single parsed file) are also linked to, but are not modified.
+func linkLocalTypes(files []FileContents, declTypes []DeclType) {
+
+	l := typeLinker{
+		decls:     make(map[string]DeclType),
+		linking:   make(map[string]bool),
+		completed: make(map[string]Type),
+	}
+
+	addDecl := func(t DeclType) {
+		key := t.Import + "." + t.Name
+		if _, exists := l.decls[key]; !exists {
+			l.decls[key] = t
+		}
+	}
+
+	for _, f := range files {
+		for _, t := range f.Types {
+			addDecl(t)
+		}
+	}
+
+	for _, t := range declTypes {
+		addDecl(t)
+	}
+
+	if len(l.decls) == 0 {
+		return
+	}
+
+	for iF := range files {
+		f := &files[iF]
+
+		f.Consts = l.linkDeclVars(f.Consts)
+		f.Vars = l.linkDeclVars(f.Vars)
+
+		for iT, t := range f.Types {
+			key := t.Import + "." + t.Name
+			l.linking[key] = true
+			f.Types[iT].Type, _ = l.link(t.Type)
+			delete(l.linking, key)
+		}
+
+		f.Functions = l.linkDeclFuncs(f.Functions)
+	}
+}
+
+type typeLinker struct {
+	// decls are the declared types, by `import.Name`
+	decls map[string]DeclType
+
+	// linking is the set of declared types currently being linked
+	linking map[string]bool
+
+	// completed holds the linked types of declarations which did not refer
+	// to any type being linked, so can be reused wherever they are referred
+	// to (this stops the linking of large packages from being exponential)
+	completed map[string]Type
+}
+
+// declaredType returns the linked type of the declaration of `name` in the
+// package `importPath`
+//
+// Returns a nil type if there is no such declaration or if the declaration
+// is currently being linked. `isCut` is true if the returned type is
+// incomplete because of a declaration being linked.
+func (l *typeLinker) declaredType(importPath string, name string) (Type, bool) {
+
+	key := importPath + "." + name
+
+	if t, ok := l.completed[key]; ok {
+		return t, false
+	}
+
+	decl, ok := l.decls[key]
+	if !ok {
+		return nil, false
+	}
+
+	if l.linking[key] {
+		return nil, true
+	}
+
+	l.linking[key] = true
+	t, isCut := l.link(decl.Type)
+	delete(l.linking, key)
+
+	if !isCut {
+		l.completed[key] = t
+	}
+
+	return t, isCut
+}
+
+// link returns a copy of `t` with the named types within it linked to their
+// declarations
+func (l *typeLinker) link(t Type) (Type, bool) {
+
+	switch t := t.(type) {
+	case TypeNamed:
+		if t.ValueType != nil || t.Import == "" {
+			return t, false
+		}
+
+		var isCut bool
+		t.ValueType, isCut = l.declaredType(t.Import, t.Name)
+		return t, isCut
+
+	case TypeGeneric:
+		typeArgs := make([]Type, 0, len(t.TypeArgs))
+		var isCut bool
+		for _, arg := range t.TypeArgs {
+			linked, argIsCut := l.link(arg)
+			typeArgs = append(typeArgs, linked)
+			isCut = isCut || argIsCut
+		}
+		t.TypeArgs = typeArgs
+
+		if t.ValueType == nil && t.Import != "" {
+			var valueIsCut bool
+			t.ValueType, valueIsCut = l.declaredType(t.Import, t.Name)
+			isCut = isCut || valueIsCut
+		}
+		return t, isCut
+
+	case TypePointer:
+		var isCut bool
+		t.ValueType, isCut = l.link(t.ValueType)
+		return t, isCut
+
+	case TypeArray:
+		var isCut bool
+		t.ValueType, isCut = l.link(t.ValueType)
+		return t, isCut
+
+	case TypeFixedArray:
+		var isCut bool
+		t.ValueType, isCut = l.link(t.ValueType)
+		return t, isCut
+
+	case TypeChan:
+		var isCut bool
+		t.ValueType, isCut = l.link(t.ValueType)
+		return t, isCut
+
+	case TypeMap:
+		var keyIsCut, valueIsCut bool
+		t.KeyType, keyIsCut = l.link(t.KeyType)
+		t.ValueType, valueIsCut = l.link(t.ValueType)
+		return t, keyIsCut || valueIsCut
+
+	case TypeStruct:
+		var embedsIsCut, fieldsIsCut bool
+		t.Embeds, embedsIsCut = l.linkStructEmbeds(t.Embeds)
+		t.Fields, fieldsIsCut = l.linkDeclVarTypes(t.Fields)
+		return t, embedsIsCut || fieldsIsCut
+
+	case TypeInterface:
+		var embedsIsCut, funcsIsCut bool
+		t.Embeds, embedsIsCut = l.linkTypes(t.Embeds)
+		t.Funcs, funcsIsCut = l.linkDeclFuncTypes(t.Funcs)
+		return t, embedsIsCut || funcsIsCut
+
+	case TypeFunc:
+		var argsIsCut, retArgsIsCut bool
+		t.Args, argsIsCut = l.linkDeclVarTypes(t.Args)
+		t.ReturnArgs, retArgsIsCut = l.linkDeclVarTypes(t.ReturnArgs)
+		return t, argsIsCut || retArgsIsCut
+
+	case TypeUnion:
+		if len(t.Terms) == 0 {
+			return t, false
+		}
+
+		terms := make([]TypeUnionTerm, 0, len(t.Terms))
+		var isCut bool
+		for _, term := range t.Terms {
+			var termIsCut bool
+			term.Type, termIsCut = l.link(term.Type)
+			terms = append(terms, term)
+			isCut = isCut || termIsCut
+		}
+		t.Terms = terms
+		return t, isCut
+	}
+
+	return t, false
+}
+
+func (l *typeLinker) linkTypes(types []Type) ([]Type, bool) {
+
+	if len(types) == 0 {
+		return types, false
+	}
+
+	linked := make([]Type, 0, len(types))
+	var isCut bool
+	for _, t := range types {
+		linkedType, typeIsCut := l.link(t)
+		linked = append(linked, linkedType)
+		isCut = isCut || typeIsCut
+	}
+	return linked, isCut
+}
+
+func (l *typeLinker) linkStructEmbeds(embeds []StructEmbed) ([]StructEmbed, bool) {
+
+	if len(embeds) == 0 {
+		return embeds, false
+	}
+
+	linked := make([]StructEmbed, 0, len(embeds))
+	var isCut bool
+	for _, e := range embeds {
+		var typeIsCut bool
+		e.Type, typeIsCut = l.link(e.Type)
+		linked = append(linked, e)
+		isCut = isCut || typeIsCut
+	}
+	return linked, isCut
+}
+
+func (l *typeLinker) linkDeclVarTypes(decls []DeclVar) ([]DeclVar, bool) {
+
+	if len(decls) == 0 {
+		return decls, false
+	}
+
+	linked := make([]DeclVar, 0, len(decls))
+	var isCut bool
+	for _, d := range decls {
+		var typeIsCut bool
+		d.Type, typeIsCut = l.link(d.Type)
+		linked = append(linked, d)
+		isCut = isCut || typeIsCut
+	}
+	return linked, isCut
+}
+
+func (l *typeLinker) linkDeclFuncTypes(decls []DeclFunc) ([]DeclFunc, bool) {
+
+	if len(decls) == 0 {
+		return decls, false
+	}
+
+	linked := make([]DeclFunc, 0, len(decls))
+	var isCut bool
+	for _, d := range decls {
+		var argsIsCut, retArgsIsCut bool
+		d.Args, argsIsCut = l.linkDeclVarTypes(d.Args)
+		d.ReturnArgs, retArgsIsCut = l.linkDeclVarTypes(d.ReturnArgs)
+		linked = append(linked, d)
+		isCut = isCut || argsIsCut || retArgsIsCut
+	}
+	return linked, isCut
+}
+
+func (l *typeLinker) linkDeclVars(decls []DeclVar) []DeclVar {
+
+	linked, _ := l.linkDeclVarTypes(decls)
+	return linked
+}
+
+func (l *typeLinker) linkDeclFuncs(decls []DeclFunc) []DeclFunc {
+
+	linked, _ := l.linkDeclFuncTypes(decls)
+	return linked
+}
@@ -74,6 +74,11 @@ func AddRequiredImports(pkg []FileContents) error {
 	for iF, file := range pkg {
 		existingImportSet := make(map[string]bool)
 		for _, i := range file.Imports {
+			// A blank import does not make the package's declarations
+			// available within the file
+			if i.Alias == "_" {
+				continue
+			}
 			existingImportSet[i.Import] = true
 		}
 
@@ -184,7 +189,7 @@ func getFileRequiredTypeImports(f FileContents) map[string]bool {
 	for _, t := range f.Types {
 		requiredTypeImports = union(
 			requiredTypeImports,
-			t.Type.RequiredImports(),
+			t.RequiredImports(),
 		)
 	}
 	for _, f := range f.Functions {
@@ -73,6 +73,34 @@ func TestAddRequiredImports(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "dot imports satisfy required imports and blank imports do not",
+			Pkg: []gopkg.FileContents{
+				{
+					Imports: []gopkg.ImportAndAlias{
+						importWithAlias("a", "."),
+						importWithAlias("b", "_"),
+					},
+					Vars: []gopkg.DeclVar{
+						varTypeNamed("A", "a"),
+						varTypeNamed("B", "b"),
+					},
+				},
+			},
+			Expected: []gopkg.FileContents{
+				{
+					Imports: []gopkg.ImportAndAlias{
+						importWithAlias("a", "."),
+						importWithAlias("b", "_"),
+						{Import: "b"},
+					},
+					Vars: []gopkg.DeclVar{
+						varTypeNamed("A", "a"),
+						varTypeNamed("B", "b"),
+					},
+				},
+			},
+		},
 		{
 			Name: "adds required imports from consts and vars",
 			Pkg: []gopkg.FileContents{
@@ -107,6 +135,62 @@ func TestAddRequiredImports(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "adds required imports from literal values of consts and vars",
+			Pkg: []gopkg.FileContents{
+				{
+					Consts: []gopkg.DeclVar{
+						{
+							Name:         "A",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "a.Second * 5",
+							LiteralValueImports: map[string]bool{
+								"a": true,
+							},
+						},
+					},
+					Vars: []gopkg.DeclVar{
+						{
+							Name:         "B",
+							Type:         gopkg.TypeNamed{Name: "C", Import: "c"},
+							LiteralValue: "b.NewC()",
+							LiteralValueImports: map[string]bool{
+								"b": true,
+							},
+						},
+					},
+				},
+			},
+			Expected: []gopkg.FileContents{
+				{
+					Imports: []gopkg.ImportAndAlias{
+						{Import: "a"},
+						{Import: "b"},
+						{Import: "c"},
+					},
+					Consts: []gopkg.DeclVar{
+						{
+							Name:         "A",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "a.Second * 5",
+							LiteralValueImports: map[string]bool{
+								"a": true,
+							},
+						},
+					},
+					Vars: []gopkg.DeclVar{
+						{
+							Name:         "B",
+							Type:         gopkg.TypeNamed{Name: "C", Import: "c"},
+							LiteralValue: "b.NewC()",
+							LiteralValueImports: map[string]bool{
+								"b": true,
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			Name: "adds required imports from types",
 			Pkg: []gopkg.FileContents{
@@ -403,6 +487,27 @@ func TestAddAliasToAllImports(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "dot and blank imports are left unchanged",
+			Pkg: []gopkg.FileContents{
+				{
+					Imports: []gopkg.ImportAndAlias{
+						importWithAlias("some/dot/import", "."),
+						importWithAlias("some/blank/import", "_"),
+						importWithAlias("other/import", ""),
+					},
+				},
+			},
+			Expected: []gopkg.FileContents{
+				{
+					Imports: []gopkg.ImportAndAlias{
+						importWithAlias("some/dot/import", "."),
+						importWithAlias("some/blank/import", "_"),
+						importWithAlias("other/import", "import"),
+					},
+				},
+			},
+		},
 		{
 			Name: "when imports have no alias adds using end of path",
 			Pkg: []gopkg.FileContents{
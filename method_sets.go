@@ -0,0 +1,417 @@
+package gopkg
+
+import (
+	"sort"
+)
+
+// MethodSets returns the value and pointer method sets of `t`, a type declared
+// in the parsed package `files` (e.g. as returned by Parse)
+//
+// The value method set holds the methods which can be called on a value of
+// type `t` and the pointer method set holds those which can be called on a
+// `*t`. As well as the methods declared on `t` (matched by
+// FuncReceiver.TypeName), these include the methods of an interface type and
+// of its embedded interfaces, and the methods promoted from the embedded
+// fields of a struct type. As with Go, a promoted method is not included if it
+// is shadowed by a field or method at a shallower depth, or if more than one
+// method or field of the same name is at the same depth.
+//
+// The methods of a named type declared outside of `files` are only known if
+// the TypeNamed has Methods (see ParseTypeChecked); its underlying struct or
+// interface type is only known if it has a ValueType (see
+// ParseDependentTypes).
+//
+// Both method sets are sorted by name. Promoted methods are returned as
+// declared; i.e. with the Receiver of the embedded type. The pointer method
+// set of an interface type is empty.
+func MethodSets(files []FileContents, t DeclType) ([]DeclFunc, []DeclFunc) {
+
+	b := newMethodSetBuilder(files)
+
+	var typ Type = TypeNamed{
+		Name:      t.Name,
+		Import:    t.Import,
+		ValueType: t.Type,
+	}
+	if t.IsAlias {
+		typ = t.Type
+	}
+
+	return b.methodSets(typ)
+}
+
+type methodSetBuilder struct {
+	// types are the declared types, by `import.Name`
+	types map[string]DeclType
+
+	// methods are the declared methods, by `import.Name` of their receiver
+	methods map[string][]DeclFunc
+}
+
+// methodSetEntry is a method or field found while building a method set
+type methodSetEntry struct {
+	method  DeclFunc
+	isField bool
+
+	// depth is the number of embedded fields the method or field is promoted
+	// through
+	depth int
+
+	// inValueSet is true if a method is in the method set of a value (and not
+	// only a pointer)
+	inValueSet bool
+}
+
+func newMethodSetBuilder(files []FileContents) methodSetBuilder {
+
+	b := methodSetBuilder{
+		types:   make(map[string]DeclType),
+		methods: make(map[string][]DeclFunc),
+	}
+
+	for _, f := range files {
+		for _, t := range f.Types {
+			key := t.Import + "." + t.Name
+			if _, exists := b.types[key]; !exists {
+				b.types[key] = t
+			}
+		}
+
+		for _, fn := range f.Functions {
+			if fn.Receiver.TypeName == "" {
+				continue
+			}
+
+			importPath := fn.Import
+			if importPath == "" {
+				importPath = f.PackageImportPath
+			}
+
+			key := importPath + "." + fn.Receiver.TypeName
+			b.methods[key] = append(b.methods[key], fn)
+		}
+	}
+
+	return b
+}
+
+func (b methodSetBuilder) methodSets(t Type) ([]DeclFunc, []DeclFunc) {
+
+	if isInterfaceType(b.underlying(t)) {
+		return b.interfaceMethods(t, make(map[string]bool)), nil
+	}
+
+	entries := make(map[string][]methodSetEntry)
+	b.collect(t, 0, false, make(map[string]bool), entries)
+
+	var value, pointer []DeclFunc
+	for _, nameEntries := range entries {
+		e, ok := selectEntry(nameEntries)
+		if !ok || e.isField {
+			continue
+		}
+
+		pointer = append(pointer, e.method)
+		if e.inValueSet {
+			value = append(value, e.method)
+		}
+	}
+
+	sortMethodsByName(value)
+	sortMethodsByName(pointer)
+
+	// A pointer to a pointer type has no methods
+	if _, isPointer := t.(TypePointer); isPointer {
+		return pointer, nil
+	}
+
+	return value, pointer
+}
+
+// collect adds the methods and fields of `t` at `depth`, and those promoted
+// from its embedded fields at greater depths, to `entries`
+//
+// `indirect` is true if `t` is reached through a pointer, in which case all
+// of its methods are in the value method set.
+func (b methodSetBuilder) collect(
+	t Type,
+	depth int,
+	indirect bool,
+	visiting map[string]bool,
+	entries map[string][]methodSetEntry,
+) {
+
+	if p, ok := t.(TypePointer); ok {
+		t = p.ValueType
+		indirect = true
+	}
+
+	t = b.followAliases(t)
+
+	if key, ok := namedTypeKey(t); ok {
+		if visiting[key] {
+			return
+		}
+		visiting[key] = true
+		defer delete(visiting, key)
+
+		for _, m := range b.declaredMethods(t) {
+			entries[m.Name] = append(entries[m.Name], methodSetEntry{
+				method:     m,
+				depth:      depth,
+				inValueSet: indirect || !m.Receiver.IsPointer,
+			})
+		}
+	}
+
+	u := b.underlying(t)
+	if isInterfaceType(u) {
+		for _, m := range b.interfaceMethods(u, visiting) {
+			entries[m.Name] = append(entries[m.Name], methodSetEntry{
+				method:     m,
+				depth:      depth,
+				inValueSet: true,
+			})
+		}
+		return
+	}
+
+	s, ok := u.(TypeStruct)
+	if !ok {
+		return
+	}
+
+	for _, f := range s.Fields {
+		entries[f.Name] = append(entries[f.Name], methodSetEntry{
+			isField: true,
+			depth:   depth,
+		})
+	}
+
+	for _, e := range s.Embeds {
+		name := embeddedFieldName(e.Type)
+		entries[name] = append(entries[name], methodSetEntry{
+			isField: true,
+			depth:   depth,
+		})
+
+		b.collect(e.Type, depth+1, indirect, visiting, entries)
+	}
+}
+
+// interfaceMethods returns the methods of the interface type `t`, including
+// those of its embedded interfaces
+//
+// Embedded named types with an unknown underlying type are ignored; see
+// interfaceMethodsAndUnknownEmbeds.
+func (b methodSetBuilder) interfaceMethods(
+	t Type,
+	visiting map[string]bool,
+) []DeclFunc {
+
+	methods, _ := b.interfaceMethodsAndUnknownEmbeds(t, visiting)
+	return methods
+}
+
+// interfaceMethodsAndUnknownEmbeds returns the methods of the interface type
+// `t`, including those of its embedded interfaces, and the embedded named
+// types whose underlying type is unknown (so whose methods are unknown)
+func (b methodSetBuilder) interfaceMethodsAndUnknownEmbeds(
+	t Type,
+	visiting map[string]bool,
+) ([]DeclFunc, []Type) {
+
+	var methods []DeclFunc
+	var unknownEmbeds []Type
+	seen := make(map[string]bool)
+	add := func(m DeclFunc) {
+		if !seen[m.Name] {
+			seen[m.Name] = true
+			methods = append(methods, m)
+		}
+	}
+
+	var addInterface func(t Type, isEmbed bool)
+	addInterface = func(t Type, isEmbed bool) {
+
+		t = b.followAliases(t)
+
+		if key, ok := namedTypeKey(t); ok {
+			if visiting[key] {
+				return
+			}
+			visiting[key] = true
+			defer delete(visiting, key)
+		}
+
+		switch u := b.underlying(t).(type) {
+		case nil:
+			if isEmbed {
+				unknownEmbeds = append(unknownEmbeds, t)
+			}
+		case TypeError:
+			add(errorMethod())
+		case TypeInterface:
+			for _, f := range u.Funcs {
+				add(f)
+			}
+			for _, e := range u.Embeds {
+				addInterface(e, true)
+			}
+		}
+	}
+
+	addInterface(t, false)
+
+	sortMethodsByName(methods)
+	return methods, unknownEmbeds
+}
+
+// declaredMethods returns the methods declared on the named type `t`
+func (b methodSetBuilder) declaredMethods(t Type) []DeclFunc {
+
+	key, _ := namedTypeKey(t)
+	if methods, ok := b.methods[key]; ok {
+		return methods
+	}
+
+	if n, ok := t.(TypeNamed); ok {
+		return n.Methods
+	}
+	return nil
+}
+
+// followAliases returns the type aliased by `t` if `t` refers to a declared
+// alias
+func (b methodSetBuilder) followAliases(t Type) Type {
+
+	seen := make(map[string]bool)
+	for {
+		key, ok := namedTypeKey(t)
+		if !ok || seen[key] {
+			return t
+		}
+		seen[key] = true
+
+		decl, ok := b.types[key]
+		if !ok || !decl.IsAlias {
+			return t
+		}
+		t = decl.Type
+	}
+}
+
+// underlying returns the underlying type of `t`, or nil if `t` is a named type
+// with an unknown underlying type
+func (b methodSetBuilder) underlying(t Type) Type {
+
+	seen := make(map[string]bool)
+	for {
+		key, ok := namedTypeKey(t)
+		if !ok {
+			return t
+		}
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+
+		if decl, ok := b.types[key]; ok {
+			t = decl.Type
+			continue
+		}
+
+		switch n := t.(type) {
+		case TypeNamed:
+			t = n.ValueType
+		case TypeGeneric:
+			t = n.ValueType
+		}
+	}
+}
+
+// selectEntry returns the entry which a selector of the entries' name refers
+// to; i.e. the only entry at the shallowest depth
+//
+// Returns false if there is more than one entry at the shallowest depth (i.e.
+// the selector is ambiguous).
+func selectEntry(entries []methodSetEntry) (methodSetEntry, bool) {
+
+	var selected methodSetEntry
+	count := 0
+	for _, e := range entries {
+		if count == 0 || e.depth < selected.depth {
+			selected = e
+			count = 1
+		} else if e.depth == selected.depth {
+			count++
+		}
+	}
+	return selected, count == 1
+}
+
+// namedTypeKey returns the `import.Name` key of the named type `t`
+//
+// Returns false if `t` is not a named type declared in a package.
+func namedTypeKey(t Type) (string, bool) {
+
+	switch t := t.(type) {
+	case TypeNamed:
+		if t.Import != "" {
+			return t.Import + "." + t.Name, true
+		}
+	case TypeGeneric:
+		if t.Import != "" {
+			return t.Import + "." + t.Name, true
+		}
+	}
+	return "", false
+}
+
+// embeddedFieldName returns the name of the field for the embedded type `t`
+//
+// e.g. for `struct{ *some_pkg.MyType }` the field name is `MyType`
+func embeddedFieldName(t Type) string {
+
+	if p, ok := t.(TypePointer); ok {
+		t = p.ValueType
+	}
+
+	switch t := t.(type) {
+	case TypeNamed:
+		return t.Name
+	case TypeGeneric:
+		return t.Name
+	case nil:
+		return ""
+	}
+
+	name, _ := t.FullType(nil)
+	return name
+}
+
+func isInterfaceType(t Type) bool {
+
+	switch t.(type) {
+	case TypeInterface, TypeError, TypeAny:
+		return true
+	}
+	return false
+}
+
+// errorMethod returns the `Error` method of the predeclared `error` interface
+func errorMethod() DeclFunc {
+	return DeclFunc{
+		Name: "Error",
+		ReturnArgs: []DeclVar{
+			{Type: TypeString{}},
+		},
+	}
+}
+
+func sortMethodsByName(methods []DeclFunc) {
+
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+}
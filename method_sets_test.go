@@ -0,0 +1,189 @@
+package gopkg_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thecodedproject/gopkg"
+)
+
+func TestMethodSets(t *testing.T) {
+
+	pkg, err := gopkg.Parse(
+		"test_packages/method_sets",
+		gopkg.ParseWithPkgImportPath("some/import/method_sets"),
+	)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		Name            string
+		Files           []gopkg.FileContents
+		Type            gopkg.DeclType
+		TypeName        string
+		ExpectedValue   []string
+		ExpectedPointer []string
+	}{
+		{
+			Name:            "struct with value and pointer receivers",
+			Files:           pkg,
+			TypeName:        "Named",
+			ExpectedValue:   []string{"Name"},
+			ExpectedPointer: []string{"Name", "SetName"},
+		},
+		{
+			Name:          "interface includes embedded interfaces and has no pointer methods",
+			Files:         pkg,
+			TypeName:      "Logger",
+			ExpectedValue: []string{"Error", "Log"},
+		},
+		{
+			Name:     "methods are promoted from embedded structs, pointers and interfaces",
+			Files:    pkg,
+			TypeName: "Service",
+			ExpectedValue: []string{
+				"Count",
+				"Error",
+				"Inc",
+				"Log",
+			},
+			ExpectedPointer: []string{
+				"Count",
+				"Error",
+				"Inc",
+				"Log",
+				"SetName",
+				"Start",
+			},
+		},
+		{
+			Name:     "alias has the method sets of the aliased type",
+			Files:    pkg,
+			TypeName: "ServiceAlias",
+			ExpectedValue: []string{
+				"Count",
+				"Error",
+				"Inc",
+				"Log",
+			},
+			ExpectedPointer: []string{
+				"Count",
+				"Error",
+				"Inc",
+				"Log",
+				"SetName",
+				"Start",
+			},
+		},
+		{
+			Name:     "defined type keeps promoted methods but not declared methods",
+			Files:    pkg,
+			TypeName: "WrappedService",
+			ExpectedValue: []string{
+				"Count",
+				"Error",
+				"Inc",
+				"Log",
+			},
+			ExpectedPointer: []string{
+				"Count",
+				"Error",
+				"Inc",
+				"Log",
+				"SetName",
+			},
+		},
+		{
+			Name:     "methods promoted through an embedded pointer are in the value set and shadowed by fields",
+			Files:    pkg,
+			TypeName: "Outer",
+			ExpectedValue: []string{
+				"Error",
+				"Inc",
+				"Log",
+				"SetName",
+				"Start",
+			},
+			ExpectedPointer: []string{
+				"Error",
+				"Inc",
+				"Log",
+				"SetName",
+				"Start",
+			},
+		},
+		{
+			Name: "methods of type checked named types are promoted",
+			Type: gopkg.DeclType{
+				Name:   "Local",
+				Import: "some/import/a",
+				Type: gopkg.TypeStruct{
+					Embeds: []gopkg.StructEmbed{
+						{
+							Type: gopkg.TypeNamed{
+								Name:   "Buffer",
+								Import: "some/import/b",
+								Methods: []gopkg.DeclFunc{
+									{
+										Name:     "Write",
+										Import:   "some/import/b",
+										Receiver: gopkg.FuncReceiver{TypeName: "Buffer", IsPointer: true},
+									},
+									{
+										Name:     "Len",
+										Import:   "some/import/b",
+										Receiver: gopkg.FuncReceiver{TypeName: "Buffer"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			ExpectedValue:   []string{"Len"},
+			ExpectedPointer: []string{"Len", "Write"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			declType := test.Type
+			if test.TypeName != "" {
+				declType = findDeclType(t, test.Files, test.TypeName)
+			}
+
+			value, pointer := gopkg.MethodSets(test.Files, declType)
+
+			require.Equal(t, test.ExpectedValue, methodNames(value))
+			require.Equal(t, test.ExpectedPointer, methodNames(pointer))
+		})
+	}
+}
+
+func findDeclType(
+	t *testing.T,
+	files []gopkg.FileContents,
+	name string,
+) gopkg.DeclType {
+
+	for _, f := range files {
+		for _, declType := range f.Types {
+			if declType.Name == name {
+				return declType
+			}
+		}
+	}
+
+	require.Fail(t, "type not found", name)
+	return gopkg.DeclType{}
+}
+
+func methodNames(methods []gopkg.DeclFunc) []string {
+
+	var names []string
+	for _, m := range methods {
+		names = append(names, m.Name)
+	}
+	return names
+}
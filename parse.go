@@ -1,19 +1,23 @@
 package gopkg
 
 import (
+	"bytes"
 	"go/ast"
+	"go/build"
+	"go/build/constraint"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"io"
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
-	"golang.org/x/tools/go/packages"
-
-	"fmt"
 )
 
 const CURRENT_PKG = "current_pkg_import"
@@ -21,6 +25,21 @@ const CURRENT_PKG = "current_pkg_import"
 // Parse parses the file or package at `inputPath` and returns its `FileContents` representation
 //
 // `inputPath` may either be a single golang source file or a directory of golang source files (i.e. a package)
+//
+// Files are returned grouped by package, ordered by PackageImportPath then
+// Filepath; so the files of a package come before those of its external test
+// package (see FileContents.IsTest). Test files can be excluded with
+// ParseExcludeTests.
+//
+// Every TypeNamed which refers to a type declared in the parsed package is
+// linked to its declaration; i.e. its ValueType is set to the declared type.
+// When `inputPath` is a single file, the other files in its directory are
+// also parsed so that types declared in them are linked too (only the file
+// at `inputPath` is returned).
+//
+// By default every file is parsed regardless of its build constraints; use
+// ParseWithBuildContext to only parse the files which match a given GOOS,
+// GOARCH and set of build tags.
 func Parse(inputPath string, opts ...ParseOption) ([]FileContents, error) {
 
 	var parseOptions parseOptions
@@ -29,6 +48,10 @@ func Parse(inputPath string, opts ...ParseOption) ([]FileContents, error) {
 	}
 
 	if parseOptions.pkgImportPath == "" {
+		if parseOptions.fsys != nil {
+			return nil, errors.New("the package import path must be set with ParseWithPkgImportPath when parsing from an fs.FS")
+		}
+
 		var err error
 		parseOptions.pkgImportPath, err = PackageImportPath(inputPath)
 		if err != nil {
@@ -36,85 +59,563 @@ func Parse(inputPath string, opts ...ParseOption) ([]FileContents, error) {
 		}
 	}
 
-	fileInfo, err := os.Stat(inputPath)
+	if parseOptions.typeChecked {
+		var err error
+		parseOptions.typeInfo, err = loadTypeCheckedPackage(inputPath, parseOptions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fileInfo, err := parseOptions.stat(inputPath)
 	if err != nil {
 		return nil, err
 	}
 
+	if parseOptions.dependentTypeCache == nil {
+		parseOptions.dependentTypeCache = newDependentTypeCache()
+	}
+
+	pkgDir := inputPath
+	if !fileInfo.IsDir() {
+		pkgDir = parseOptions.dir(inputPath)
+	}
+
+	sources, err := readPackageSources(pkgDir, parseOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	parseOptions.primaryPackage, err = primaryPackageName(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	if parseOptions.dependentTypesDepth != 0 {
+		parseOptions, err = withCurrentPackageTypes(parseOptions, sources)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if fileInfo.IsDir() {
-		return parseSingleDirectory(inputPath, parseOptions)
+		files, err := parseSingleDirectory(inputPath, parseOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		linkLocalTypes(files, nil)
+
+		return files, nil
+	}
+
+	files, err := parseSingleFile(inputPath, parseOptions)
+	if err != nil {
+		return nil, err
 	}
 
-	return parseSingleFile(inputPath, parseOptions)
+	if len(files) == 0 {
+		return files, nil
+	}
+
+	siblingTypes, err := siblingTypeDecls(
+		inputPath,
+		files[0].PackageName,
+		parseOptions,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	linkLocalTypes(files, siblingTypes)
+
+	return files, nil
 }
 
-func parseSingleDirectory(
+// ParseSource parses a single golang source file from `src` rather than from
+// disk and returns its `FileContents` representation
+//
+// `filename` is only used as the Filepath of the result (and in errors).
+func ParseSource(
+	filename string,
+	src []byte,
+	pkgImportPath string,
+	opts ...ParseOption,
+) ([]FileContents, error) {
+
+	return ParseSources(
+		map[string][]byte{filename: src},
+		pkgImportPath,
+		opts...,
+	)
+}
+
+// ParseSources parses the golang source files in `sources` (a map of filename
+// to source) rather than from disk and returns their `FileContents`
+// representations, grouped by package in the same way as Parse
+//
+// e.g. this can be used to parse code produced by another generator step
+// without writing it to disk.
+func ParseSources(
+	sources map[string][]byte,
+	pkgImportPath string,
+	opts ...ParseOption,
+) ([]FileContents, error) {
+
+	if pkgImportPath == "" {
+		return nil, errors.New("package import path cannot be empty")
+	}
+
+	var parseOptions parseOptions
+	for _, opt := range opts {
+		parseOptions = opt(parseOptions)
+	}
+
+	if parseOptions.typeChecked {
+		return nil, errors.New("ParseTypeChecked is not supported when parsing sources")
+	}
+
+	parseOptions.pkgImportPath = pkgImportPath
+
+	if parseOptions.dependentTypeCache == nil {
+		parseOptions.dependentTypeCache = newDependentTypeCache()
+	}
+
+	var err error
+	parseOptions.primaryPackage, err = primaryPackageName(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	if parseOptions.dependentTypesDepth != 0 {
+		parseOptions, err = withCurrentPackageTypes(parseOptions, sources)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pkgContents := make([]FileContents, 0, len(sources))
+	for filename, src := range sources {
+
+		if parseOptions.excludeTests && isTestFile(filename) {
+			continue
+		}
+
+		fileContents, err := parseSource(filename, src, parseOptions)
+		if err != nil {
+			return nil, err
+		}
+		pkgContents = append(pkgContents, fileContents...)
+	}
+
+	sortByPackage(pkgContents)
+
+	linkLocalTypes(pkgContents, nil)
+
+	return pkgContents, nil
+}
+
+// readPackageSources reads the source of each `.go` file in `dir` which is
+// parsed with `parseOpts`, as a map of filename to source
+func readPackageSources(
 	dir string,
 	parseOpts parseOptions,
-) ([]FileContents, error) {
+) (map[string][]byte, error) {
+
+	entries, err := parseOpts.readDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string][]byte)
+	for _, entry := range entries {
+
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+
+		if parseOpts.excludeTests && isTestFile(entry.Name()) {
+			continue
+		}
+
+		filepath := parseOpts.joinPath(dir, entry.Name())
+		src, err := parseOpts.readFile(filepath)
+		if err != nil {
+			return nil, err
+		}
+
+		match, err := parseOpts.matchBuildContext(filepath, src)
+		if err != nil {
+			return nil, err
+		}
+
+		if match {
+			sources[filepath] = src
+		}
+	}
+
+	return sources, nil
+}
+
+// primaryPackageName returns the name of the package of the directory whose
+// files are `sources` (a map of filename to source); i.e. the package which
+// is given the directory's import path
+//
+// A directory may also contain files of other packages, such as a
+// `//go:build ignore` generator in `package main`. The primary package is the
+// package of the most non-test files which are not excluded by an `ignore`
+// build tag (ties are broken by package name). If the directory only has test
+// files, then it is the package which they test. Only the package clause and
+// build constraints of each file are read.
+func primaryPackageName(sources map[string][]byte) (string, error) {
+
+	counts := make(map[string]int)
+	ignoredCounts := make(map[string]int)
+	testCounts := make(map[string]int)
+
 	fset := token.NewFileSet()
-	pkgs, err := parser.ParseDir(
-		fset,
-		dir,
-		nil,
-		parser.ParseComments,
+	for filename, src := range sources {
+		f, err := parser.ParseFile(
+			fset,
+			filename,
+			src,
+			parser.PackageClauseOnly|parser.ParseComments,
+		)
+		if err != nil {
+			return "", err
+		}
+
+		name := f.Name.Name
+		if isTestFile(filename) {
+			testCounts[strings.TrimSuffix(name, "_test")]++
+			continue
+		}
+
+		buildConstraint, err := buildConstraintFromAstFile(f)
+		if err != nil {
+			return "", err
+		}
+
+		if mentionsBuildTag(buildConstraint, "ignore") {
+			ignoredCounts[name]++
+		} else {
+			counts[name]++
+		}
+	}
+
+	for _, c := range []map[string]int{counts, ignoredCounts, testCounts} {
+		var primary string
+		for name, count := range c {
+			if primary == "" || count > c[primary] ||
+				(count == c[primary] && name < primary) {
+
+				primary = name
+			}
+		}
+		if primary != "" {
+			return primary, nil
+		}
+	}
+	return "", nil
+}
+
+// mentionsBuildTag returns true if the build constraint expression
+// `buildConstraint` refers to `tag`
+func mentionsBuildTag(buildConstraint string, tag string) bool {
+
+	if buildConstraint == "" {
+		return false
+	}
+
+	expr, err := constraint.Parse("//go:build " + buildConstraint)
+	if err != nil {
+		return false
+	}
+
+	mentioned := false
+	expr.Eval(func(t string) bool {
+		if t == tag {
+			mentioned = true
+		}
+		return true
+	})
+	return mentioned
+}
+
+// packageImportPathOf returns the import path of the package `pkgName`
+// declared by a file in the directory with import path `dirImportPath` and
+// primary package `primaryPackage` (see primaryPackageName)
+//
+// The primary package has the directory's import path and its external test
+// package has the import path suffixed with `_test`. Any other package (e.g.
+// a `//go:build ignore` generator in `package main`) cannot be imported, so
+// is given the key `dirImportPath#pkgName` to keep it separate.
+func packageImportPathOf(
+	dirImportPath string,
+	primaryPackage string,
+	pkgName string,
+) string {
+
+	if pkgName == primaryPackage {
+		return dirImportPath
+	}
+
+	// External test packages have their own import path
+	isExternalTest := strings.HasSuffix(pkgName, "_test") &&
+		(primaryPackage == "" || pkgName == primaryPackage+"_test")
+
+	if isExternalTest {
+		if strings.HasSuffix(dirImportPath, "_test") {
+			return dirImportPath
+		}
+		return dirImportPath + "_test"
+	}
+
+	if primaryPackage == "" {
+		return dirImportPath
+	}
+
+	return dirImportPath + "#" + pkgName
+}
+
+// withCurrentPackageTypes adds the types declared in `sources` (the files of
+// the package being parsed) to the dependent type cache, so that named types
+// in the current package can also be resolved
+func withCurrentPackageTypes(
+	parseOpts parseOptions,
+	sources map[string][]byte,
+) (parseOptions, error) {
+
+	if parseOpts.dependentTypeCache == nil {
+		parseOpts.dependentTypeCache = newDependentTypeCache()
+	}
+
+	err := parseOpts.dependentTypeCache.addPackageSources(
+		parseOpts.pkgImportPath,
+		parseOpts.primaryPackage,
+		sources,
 	)
+	if err != nil {
+		return parseOptions{}, err
+	}
+
+	return parseOpts, nil
+}
+
+func parseSingleDirectory(
+	dir string,
+	parseOpts parseOptions,
+) ([]FileContents, error) {
+
+	entries, err := parseOpts.readDir(dir)
 	if err != nil {
 		return nil, err
 	}
 
 	pkgContents := make([]FileContents, 0)
 
-	for _, pkg := range pkgs {
+	for _, entry := range entries {
 
-		for filepath, fileNode := range pkg.Files {
-
-			fileContents, err := fileContentsFromAstFile(
-				parseOpts,
-				filepath,
-				fileNode,
-				fset,
-			)
-			if err != nil {
-				return nil, err
-			}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
 
-			fileContents.PackageName = pkg.Name
-			fileContents.PackageImportPath = parseOpts.pkgImportPath
-			fileContents.Filepath = filepath
+		if parseOpts.excludeTests && isTestFile(entry.Name()) {
+			continue
+		}
 
-			pkgContents = append(
-				pkgContents,
-				fileContents,
-			)
+		fileContents, err := parseSingleFile(
+			parseOpts.joinPath(dir, entry.Name()),
+			parseOpts,
+		)
+		if err != nil {
+			return nil, err
 		}
+
+		pkgContents = append(pkgContents, fileContents...)
 	}
 
-	sort.Slice(pkgContents, func(i, j int) bool {
-		return pkgContents[i].Filepath < pkgContents[j].Filepath
-	})
+	sortByPackage(pkgContents)
 
 	return pkgContents, nil
 }
 
+// sortByPackage sorts `files` by PackageImportPath and then by Filepath, so
+// that the files of each package are grouped together
+func sortByPackage(files []FileContents) {
+
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].PackageImportPath != files[j].PackageImportPath {
+			return files[i].PackageImportPath < files[j].PackageImportPath
+		}
+		return files[i].Filepath < files[j].Filepath
+	})
+}
+
+func isTestFile(filepath string) bool {
+	return strings.HasSuffix(filepath, "_test.go")
+}
+
+// siblingTypeDecls returns the types declared in the other files of package
+// `packageName` in the directory of the file at `filepath`, so that the types
+// declared in the rest of its package can be linked to it
+//
+// Only the package clause of each file is read to select the files of the
+// package, and only the type declarations of those files are parsed; dot
+// imports, dependent types and type checking are not used for them (in the
+// same way as the types added by dependentTypeCache.addPackageSources).
+func siblingTypeDecls(
+	filepath string,
+	packageName string,
+	parseOpts parseOptions,
+) ([]DeclType, error) {
+
+	dir := parseOpts.dir(filepath)
+	sources, err := readPackageSources(dir, parseOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	self := parseOpts.joinPath(dir, parseOpts.base(filepath))
+
+	filenames := make([]string, 0, len(sources))
+	for filename := range sources {
+		if filename != self {
+			filenames = append(filenames, filename)
+		}
+	}
+	sort.Strings(filenames)
+
+	declOpts := parseOptions{
+		pkgImportPath: packageImportPathOf(
+			parseOpts.pkgImportPath,
+			parseOpts.primaryPackage,
+			packageName,
+		),
+	}
+
+	var declTypes []DeclType
+	for _, filename := range filenames {
+		src := sources[filename]
+
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, filename, src, parser.PackageClauseOnly)
+		if err != nil {
+			return nil, err
+		}
+
+		if f.Name.Name != packageName {
+			continue
+		}
+
+		f, err = parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		imports, err := parseImportsFromAstFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		fileImports := buildFileAliasesAndImports(declOpts.pkgImportPath, imports)
+
+		fileOpts := declOpts
+		fileOpts.fileSet = fset
+
+		for _, decl := range f.Decls {
+			d, ok := decl.(*ast.GenDecl)
+			if !ok || d.Tok != token.TYPE {
+				continue
+			}
+
+			for _, declSpec := range d.Specs {
+				s := declSpec.(*ast.TypeSpec)
+
+				typeOpts := withTypeParamsInScope(
+					fileOpts,
+					typeParamNames(s.TypeParams)...,
+				)
+
+				typeParams, err := getTypeParamsFromFieldList(typeOpts, fileImports, s.TypeParams)
+				if err != nil {
+					return nil, err
+				}
+
+				fullType, err := getDeclaredType(typeOpts, fileImports, s)
+				if err != nil {
+					return nil, err
+				}
+
+				declTypes = append(declTypes, DeclType{
+					Name:       s.Name.Name,
+					Import:     declOpts.pkgImportPath,
+					TypeParams: typeParams,
+					Type:       fullType,
+					IsAlias:    s.Assign.IsValid(),
+				})
+			}
+		}
+	}
+
+	return declTypes, nil
+}
+
 func parseSingleFile(
 	filepath string,
 	parseOpts parseOptions,
 ) ([]FileContents, error) {
+
+	src, err := parseOpts.readFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSource(filepath, src, parseOpts)
+}
+
+func parseSource(
+	filepath string,
+	src []byte,
+	parseOpts parseOptions,
+) ([]FileContents, error) {
+
+	match, err := parseOpts.matchBuildContext(filepath, src)
+	if err != nil {
+		return nil, err
+	}
+
+	if !match {
+		return nil, nil
+	}
+
 	fset := token.NewFileSet()
 	f, err := parser.ParseFile(
 		fset,
 		filepath,
-		nil,
+		src,
 		parser.ParseComments,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	parseOpts.pkgImportPath = packageImportPathOf(
+		parseOpts.pkgImportPath,
+		parseOpts.primaryPackage,
+		f.Name.Name,
+	)
+
+	parseOpts = parseOpts.withLoadDir(filepath)
+
+	parseOpts.fileSet = fset
+	if parseOpts.typeInfo != nil {
+		parseOpts.fileObjects = parseOpts.typeInfo.fileObjects(filepath)
+	}
+
 	fileContents, err := fileContentsFromAstFile(
 		parseOpts,
-		filepath,
+		src,
 		f,
 		fset,
 	)
@@ -125,43 +626,65 @@ func parseSingleFile(
 	fileContents.PackageName = f.Name.String()
 	fileContents.PackageImportPath = parseOpts.pkgImportPath
 	fileContents.Filepath = filepath
+	fileContents.IsTest = isTestFile(filepath)
 
 	return []FileContents{fileContents}, nil
 }
 
 func fileContentsFromAstFile(
 	parseOpts parseOptions,
-	filepath string,
+	src []byte,
 	f *ast.File,
 	fileSet *token.FileSet,
 ) (FileContents, error) {
 
-	fp, err := os.Open(filepath)
+	var err error
+	var contents FileContents
+	for _, cg := range f.Comments {
+		if cg.Pos() > f.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if generatedBy, ok := generatorFromHeader(c.Text); ok {
+				contents.GeneratedBy = generatedBy
+			}
+		}
+	}
+
+	contents.BuildConstraint, err = buildConstraintFromAstFile(f)
 	if err != nil {
 		return FileContents{}, err
 	}
-	defer fp.Close()
 
-	var contents FileContents
-	if f.Doc != nil {
-		var err error
-		contents.DocString, err = readFromFileSet(fp, fileSet, f.Doc.Pos(), f.Doc.End())
-		if err != nil {
-			return FileContents{}, err
-		}
+	contents.DocString, _, err = docAndDirectives(src, fileSet, f.Doc)
+	if err != nil {
+		return FileContents{}, err
 	}
 
+	contents.Directives = fileDirectivesFromAstFile(f)
+
 	contents.Imports, err = parseImportsFromAstFile(f)
 	if err != nil {
 		return FileContents{}, err
 	}
 
+	contents.CgoPreamble, err = cgoPreambleFromAstFile(src, fileSet, f)
+	if err != nil {
+		return FileContents{}, err
+	}
+
 	fileImports := buildFileAliasesAndImports(parseOpts.pkgImportPath, contents.Imports)
 
+	parseOpts.dotImportTypes, err = dotImportedTypes(parseOpts, contents.Imports)
+	if err != nil {
+		return FileContents{}, err
+	}
+
+	constGroup, varGroup := 0, 0
 	for _, d := range f.Decls {
 		switch decl := d.(type) {
 		case *ast.FuncDecl:
-			f, err := getDeclFunc(parseOpts, fileSet, fileImports, fp, decl)
+			f, err := getDeclFunc(parseOpts, fileSet, fileImports, src, decl)
 			if err != nil {
 				return FileContents{}, err
 			}
@@ -169,20 +692,32 @@ func fileContentsFromAstFile(
 
 		case *ast.GenDecl:
 
-			var docString string
-			if decl.Doc != nil {
-				docString, err = readFromFileSet(fp, fileSet, decl.Doc.Pos(), decl.Doc.End())
-				if err != nil {
-					return FileContents{}, err
-				}
+			docString, directives, err := docAndDirectives(src, fileSet, decl.Doc)
+			if err != nil {
+				return FileContents{}, err
 			}
 
-			for _, declSpec := range decl.Specs {
+			var prevDeclVars []DeclVar
+			for iSpec, declSpec := range decl.Specs {
 
 				switch s := declSpec.(type) {
 				case *ast.TypeSpec:
 
-					fullType, err := getFullType(parseOpts, fileImports, s.Type)
+					typeOpts := withTypeParamsInScope(
+						parseOpts,
+						typeParamNames(s.TypeParams)...,
+					)
+
+					typeParams, err := getTypeParamsFromFieldList(typeOpts, fileImports, s.TypeParams)
+					if err != nil {
+						return FileContents{}, err
+					}
+
+					fullType, err := getDeclaredType(
+						typeOpts,
+						fileImports,
+						s,
+					)
 					if err != nil {
 						return FileContents{}, err
 					}
@@ -190,10 +725,13 @@ func fileContentsFromAstFile(
 					contents.Types = append(
 						contents.Types,
 						DeclType{
-							Name:      s.Name.Name,
-							Import:    parseOpts.pkgImportPath,
-							Type:      fullType,
-							DocString: docString,
+							Name:       s.Name.Name,
+							Import:     parseOpts.pkgImportPath,
+							TypeParams: typeParams,
+							Type:       fullType,
+							IsAlias:    s.Assign.IsValid(),
+							DocString:  docString,
+							Directives: directives,
 						},
 					)
 				case *ast.ValueSpec:
@@ -201,7 +739,7 @@ func fileContentsFromAstFile(
 					declVars, err := declVarsFromAstValueSpec(
 						parseOpts,
 						fileImports,
-						fp,
+						src,
 						fileSet,
 						s,
 					)
@@ -209,25 +747,82 @@ func fileContentsFromAstFile(
 						return FileContents{}, err
 					}
 
+					isImplicit := decl.Tok == token.CONST &&
+						s.Type == nil &&
+						len(s.Values) == 0
+
+					// The doc of a parenthesised block belongs to the whole
+					// block, rather than to each of its specs
+					if decl.Lparen.IsValid() {
+						if iSpec == 0 && len(declVars) > 0 {
+							declVars[0].GroupDocString = docString
+							declVars[0].GroupDirectives = directives
+						}
+					}
+
 					for i := range declVars {
-						if declVars[i].DocString == "" {
+						if !decl.Lparen.IsValid() &&
+							declVars[i].DocString == "" &&
+							len(declVars[i].Directives) == 0 {
+
 							declVars[i].DocString = docString
+							declVars[i].Directives = directives
+						}
+
+						if isImplicit && i < len(prevDeclVars) {
+							declVars[i].Type = prevDeclVars[i].Type
+							declVars[i].LiteralValue = prevDeclVars[i].LiteralValue
+							declVars[i].LiteralValueImports = prevDeclVars[i].LiteralValueImports
+							declVars[i].IsImplicit = true
 						}
 					}
+					prevDeclVars = declVars
 
 					if decl.Tok == token.VAR {
+						for i := range declVars {
+							declVars[i].Group = varGroup
+						}
 						contents.Vars = append(contents.Vars, declVars...)
 					} else if decl.Tok == token.CONST {
+						for i := range declVars {
+							declVars[i].Group = constGroup
+							declVars[i].Iota = iSpec
+						}
 						contents.Consts = append(contents.Consts, declVars...)
 					}
 				}
 			}
+
+			if decl.Tok == token.VAR {
+				varGroup++
+			} else if decl.Tok == token.CONST {
+				constGroup++
+			}
 		}
 	}
 
 	return contents, nil
 }
 
+// getDeclaredType returns the type given in the type declaration `s`
+//
+// When resolving dependent types, the type being declared is not resolved
+// again within its own declaration (i.e. for a recursive type).
+func getDeclaredType(
+	parseOpts parseOptions,
+	imports map[string]string,
+	s *ast.TypeSpec,
+) (Type, error) {
+
+	if cache := parseOpts.dependentTypeCache; cache != nil {
+		key := imports[CURRENT_PKG] + "." + s.Name.Name
+		cache.resolving[key] = true
+		defer delete(cache.resolving, key)
+	}
+
+	return getFullType(parseOpts, imports, s.Type)
+}
+
 func parseImportsFromAstFile(
 	fileAst *ast.File,
 ) ([]ImportAndAlias, error) {
@@ -257,6 +852,7 @@ func parseImportsFromAstFile(
 // assumed to be the last element of import path.
 // For the current package, a special constant `CURRENT_PKG` is used to indicate
 // that this is the current package.
+// Dot (`.`) and blank (`_`) imports have no local alias, so are not included.
 // This mapping is used to assign the correct import path to every parsed
 // declaration (the local aliases are not returned in the parsed FileContents
 // struct)
@@ -267,6 +863,11 @@ func buildFileAliasesAndImports(
 	fileImports := make(map[string]string)
 	fileImports[CURRENT_PKG] = currentPkgImportPath
 	for _, i := range imports {
+		if i.Alias == "." || i.Alias == "_" {
+			// Dot and blank imports are never referenced with an alias
+			continue
+		}
+
 		localAlias := i.Alias
 		if localAlias == "" {
 			_, localAlias = path.Split(i.Import)
@@ -282,11 +883,22 @@ func getDeclFunc(
 	parseOpts parseOptions,
 	fileSet *token.FileSet,
 	fileImports map[string]string,
-	fp *os.File,
+	src []byte,
 	decl *ast.FuncDecl,
 ) (DeclFunc, error) {
 
-	receiver, err := getFuncReceiverFromFieldList(parseOpts, decl.Recv)
+	receiver, err := getFuncReceiverFromFieldList(parseOpts, fileImports, decl.Recv)
+	if err != nil {
+		return DeclFunc{}, err
+	}
+
+	parseOpts = withTypeParamsInScope(parseOpts, receiver.TypeParams...)
+	parseOpts = withTypeParamsInScope(
+		parseOpts,
+		typeParamNames(decl.Type.TypeParams)...,
+	)
+
+	typeParams, err := getTypeParamsFromFieldList(parseOpts, fileImports, decl.Type.TypeParams)
 	if err != nil {
 		return DeclFunc{}, err
 	}
@@ -307,13 +919,14 @@ func getDeclFunc(
 		Name:       decl.Name.String(),
 		Import:     parseOpts.pkgImportPath,
 		Receiver:   receiver,
+		TypeParams: typeParams,
 		Args:       args,
 		ReturnArgs: retArgs,
 		VariadicLastArg: variadicLastArg,
 	}
 
 	if decl.Body != nil {
-		body, err := readFromFileSet(fp, fileSet, decl.Body.Lbrace+1, decl.Body.Rbrace)
+		body, err := readFromFileSet(src, fileSet, decl.Body.Lbrace+1, decl.Body.Rbrace)
 		if err != nil {
 			return DeclFunc{}, err
 		}
@@ -322,22 +935,19 @@ func getDeclFunc(
 		}
 	}
 
-	if decl.Doc != nil {
-		docString, err := readFromFileSet(fp, fileSet, decl.Doc.Pos(), decl.Doc.End())
-		if err != nil {
-			return DeclFunc{}, err
-		}
-		f.DocString = docString
+	f.DocString, f.Directives, err = docAndDirectives(src, fileSet, decl.Doc)
+	if err != nil {
+		return DeclFunc{}, err
 	}
 
 	return f, nil
 }
 
-// readFromFileSet read bytes from the open *os.File, `fp`, from the
+// readFromFileSet read bytes from the file source, `src`, from the
 // the byte at position `from` in the fileset upto, but not including, the
 // byte at `to` in the fileset.
 func readFromFileSet(
-	fp *os.File,
+	src []byte,
 	fileSet *token.FileSet,
 	from token.Pos,
 	to token.Pos,
@@ -348,13 +958,13 @@ func readFromFileSet(
 		return "", errors.New("position is not in the fileset")
 	}
 
-	buf := make([]byte, int64(to-from))
-	_, err := fp.ReadAt(buf, int64(from)-int64(fsFile.Base()))
-	if err != nil {
-		return "", err
+	start := int(from) - fsFile.Base()
+	end := int(to) - fsFile.Base()
+	if start < 0 || end > len(src) || start > end {
+		return "", errors.New("position is outside of the file source")
 	}
 
-	return string(buf), nil
+	return string(src[start:end]), nil
 }
 
 // getDeclVarsFromFieldList returns an ordered list of declared variables
@@ -392,15 +1002,26 @@ func getDeclVarsFromFieldList(
 
 		if len(f.Names) == 0 {
 			typeList = append(typeList, DeclVar{
-				Type: fieldType,
+				Type:      fieldType,
+				DocString: commentGroupText(f.Doc),
+				Comment:   commentGroupText(f.Comment),
 			})
 		} else {
-			for _, name := range f.Names {
-				typeList = append(typeList, DeclVar{
+			for i, name := range f.Names {
+				d := DeclVar{
 					Name:      name.String(),
 					Type:      fieldType,
 					StructTag: reflect.StructTag(tag),
-				})
+				}
+
+				// The doc and comment of `A, B int // c` are only kept on
+				// `A`, so that they are written once for the whole field
+				if i == 0 {
+					d.DocString = commentGroupText(f.Doc)
+					d.Comment = commentGroupText(f.Comment)
+				}
+
+				typeList = append(typeList, d)
 			}
 		}
 	}
@@ -449,6 +1070,8 @@ func getDeclFuncsFromFieldList(
 					Args:       args,
 					ReturnArgs: retArgs,
 					VariadicLastArg: variadicLastArg,
+					DocString:  commentGroupText(method.Doc),
+					Comment:    commentGroupText(method.Comment),
 				})
 			}
 		}
@@ -457,13 +1080,29 @@ func getDeclFuncsFromFieldList(
 	return funcs, nil
 }
 
-// handleVariadicLastArg will detect if the last parameter of a func type is variadic
+// commentGroupText returns the raw text of each comment in `cg` (including
+// the `//` or `/*` markers), one comment per line
 //
-// If the last arg is variadic it will return true *and* will _strip_ the `ast.Ellipsis`
-// from the type of of the last arg in `funcParams` - this is so it can be passed as if
-// it is not variadic (i.e. with the same `getFullType` method)
+// Returns an empty string if `cg` is nil.
+func commentGroupText(cg *ast.CommentGroup) string {
+
+	if cg == nil {
+		return ""
+	}
+
+	lines := make([]string, 0, len(cg.List))
+	for _, c := range cg.List {
+		lines = append(lines, c.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// handleVariadicLastArg will detect if the last parameter of a func type is variadic
 //
-// If the last arg is not variabic it will return false and `funcParams` will remain unchanged
+// The type of a variadic arg is parsed as the type of each element (i.e. `int`
+// for `...int`), so this is needed to tell it apart from a non-variadic arg.
+// `funcParams` is not modified, as the same ast may be parsed more than once
+// when resolving dependent types.
 func handleVariadicLastArg(funcParams *ast.FieldList) bool {
 
 	// todo: implement
@@ -474,57 +1113,141 @@ func handleVariadicLastArg(funcParams *ast.FieldList) bool {
 
 	p := funcParams.List[len(funcParams.List) - 1]
 
-	e, isVariadic := p.Type.(*ast.Ellipsis)
+	_, isVariadic := p.Type.(*ast.Ellipsis)
 
-	if !isVariadic {
-		return false
+	return isVariadic
+}
+
+func getFuncReceiverFromFieldList(
+	parseOpts parseOptions,
+	fileImports map[string]string,
+	fieldList *ast.FieldList,
+) (FuncReceiver, error) {
+
+	if fieldList == nil {
+		return FuncReceiver{}, nil
 	}
 
-	funcParams.List[len(funcParams.List) - 1].Type = e.Elt
+	// Only the name of the receiver type is kept, so there is no need to
+	// resolve its declaration
+	parseOpts.dependentTypesDepth = 0
 
-	return true
+	types, err := getDeclVarsFromFieldList(parseOpts, fileImports, fieldList)
+	if err != nil {
+		return FuncReceiver{}, err
+	}
+
+	if len(types) != 1 {
+		return FuncReceiver{}, errors.New("More than one receiver in ast for method")
+	}
+
+	receiverType := types[0]
+
+	receiver := FuncReceiver{
+		VarName: receiverType.Name,
+	}
+
+	recvType := receiverType.Type
+	if p, ok := recvType.(TypePointer); ok {
+		receiver.IsPointer = true
+		recvType = p.ValueType
+	}
+
+	switch t := recvType.(type) {
+	case TypeNamed:
+		receiver.TypeName = t.Name
+	case TypeGeneric:
+		receiver.TypeName = t.Name
+
+		// The type args of a generic receiver declare the type params of the
+		// method, so they are always plain identifiers
+		for _, arg := range t.TypeArgs {
+			argNamed, ok := arg.(TypeNamed)
+			if !ok {
+				return FuncReceiver{}, errors.New("expected identifier for generic receiver type param")
+			}
+			receiver.TypeParams = append(receiver.TypeParams, argNamed.Name)
+		}
+	default:
+		return FuncReceiver{}, errors.New("expected TypeNamed in receiver but found different type")
+	}
+
+	return receiver, nil
 }
 
-func getFuncReceiverFromFieldList(
+// typeParamNames returns the names of all the type params declared in a type
+// param field list (e.g. `[K comparable, V any]` returns `[K V]`)
+func typeParamNames(fieldList *ast.FieldList) []string {
+
+	if fieldList == nil {
+		return nil
+	}
+
+	var names []string
+	for _, f := range fieldList.List {
+		for _, name := range f.Names {
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+// getTypeParamsFromFieldList returns the type params (and their constraints)
+// declared in a type param field list.
+//
+// The type param names must already be in scope in `parseOpts` as the
+// constraints may reference them (e.g. `[S ~[]E, E any]`)
+func getTypeParamsFromFieldList(
 	parseOpts parseOptions,
+	imports map[string]string,
 	fieldList *ast.FieldList,
-) (FuncReceiver, error) {
+) ([]TypeParam, error) {
 
 	if fieldList == nil {
-		return FuncReceiver{}, nil
+		return nil, nil
 	}
 
-	types, err := getDeclVarsFromFieldList(parseOpts, nil, fieldList)
-	if err != nil {
-		return FuncReceiver{}, err
-	}
+	var typeParams []TypeParam
+	for _, f := range fieldList.List {
+		constraint, err := getFullType(parseOpts, imports, f.Type)
+		if err != nil {
+			return nil, err
+		}
 
-	if len(types) != 1 {
-		return FuncReceiver{}, errors.New("More than one receiver in ast for method")
+		for _, name := range f.Names {
+			typeParams = append(typeParams, TypeParam{
+				Name:       name.Name,
+				Constraint: constraint,
+			})
+		}
 	}
+	return typeParams, nil
+}
 
-	receiverType := types[0]
+// withTypeParamsInScope returns a copy of `parseOpts` with `names` added to
+// the set of type params in scope.
+//
+// Any identifiers matching a type param in scope are parsed as a TypeNamed
+// with no import.
+func withTypeParamsInScope(
+	parseOpts parseOptions,
+	names ...string,
+) parseOptions {
 
-	receiver := FuncReceiver{
-		VarName: receiverType.Name,
+	if len(names) == 0 {
+		return parseOpts
 	}
-	if p, ok := receiverType.Type.(TypePointer); ok {
-		receiver.IsPointer = true
 
-		t, ok := p.ValueType.(TypeNamed)
-		if !ok {
-			return FuncReceiver{}, errors.New("expected TypeNamed in pointer receiver but found different type")
-		}
-		receiver.TypeName = t.Name
-	} else {
-		t, ok := receiverType.Type.(TypeNamed)
-		if !ok {
-			return FuncReceiver{}, errors.New("expected TypeNamed in receiver but found different type")
-		}
-		receiver.TypeName = t.Name
+	typeParams := make(map[string]bool, len(parseOpts.typeParams)+len(names))
+	for name := range parseOpts.typeParams {
+		typeParams[name] = true
+	}
+	for _, name := range names {
+		typeParams[name] = true
 	}
+	parseOpts.typeParams = typeParams
 
-	return receiver, nil
+	return parseOpts
 }
 
 func getFullType(
@@ -537,26 +1260,71 @@ func getFullType(
 
 	switch t := t.(type) {
 	case *ast.ArrayType:
-		if t.Len != nil {
-			return nil, errors.New("[...]T array types not supported")
-		}
 		fullType, err := getFullType(parseOpts, imports, t.Elt)
 		if err != nil {
 			return nil, err
 		}
+
+		if t.Len != nil {
+			return TypeFixedArray{
+				Len:        types.ExprString(t.Len),
+				LenImports: exprImportQualifiers(imports, t.Len),
+				ValueType:  fullType,
+			}, nil
+		}
+
 		return TypeArray{
 			ValueType: fullType,
 		}, nil
 
+	case *ast.ChanType:
+		valueType, err := getFullType(parseOpts, imports, t.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		dir := ChanDirBoth
+		switch t.Dir {
+		case ast.SEND:
+			dir = ChanDirSend
+		case ast.RECV:
+			dir = ChanDirRecv
+		}
+
+		return TypeChan{
+			Dir:       dir,
+			ValueType: valueType,
+		}, nil
+
 	case *ast.Ident:
+		if parseOpts.typeParams[t.Name] {
+			return TypeNamed{
+				Name: t.Name,
+			}, nil
+		}
+
 		if isBuiltInType(t.Name) {
 			return typeFromString(t.Name), nil
 		}
 
-		importPath := imports[CURRENT_PKG]
+		if typeName := typeNameAt(parseOpts, t); typeName != nil {
+			return parseOpts.typeInfo.namedTypeFromTypeName(typeName)
+		}
+
+		importPath, ok := parseOpts.dotImportTypes[t.Name]
+		if !ok {
+			importPath = imports[CURRENT_PKG]
+		}
+
+		valueType, err := getDependentType(parseOpts, importPath, t.Name, false)
+		if err != nil {
+			return nil, err
+		}
+
 		return TypeNamed{
-			Name:   t.Name,
-			Import: importPath,
+			Name:      t.Name,
+			Import:    importPath,
+			ValueType: valueType,
 		}, nil
 
 	case *ast.MapType:
@@ -576,6 +1344,17 @@ func getFullType(
 			ValueType: valueType,
 		}, nil
 
+	// i.e. the type of a variadic arg, which is given by the type of each
+	// element (see handleVariadicLastArg)
+	//	`...int`
+	case *ast.Ellipsis:
+		return getFullType(parseOpts, imports, t.Elt)
+
+	// i.e. a parenthesised type
+	//	`chan (<-chan int)`
+	case *ast.ParenExpr:
+		return getFullType(parseOpts, imports, t.X)
+
 	case *ast.StarExpr:
 		fullType, err := getFullType(parseOpts, imports, t.X)
 		if err != nil {
@@ -594,42 +1373,76 @@ func getFullType(
 			return nil, errors.New("uknown selector X")
 		}
 
+		if typeName := typeNameAt(parseOpts, t.Sel); typeName != nil {
+			return parseOpts.typeInfo.namedTypeFromTypeName(typeName)
+		}
+
 		importPath, ok := imports[imp.Name]
 		if !ok {
 			return nil, errors.New("unknown import path '" + imp.Name + "'")
 		}
 
-		var valueType Type
-		if parseOpts.dependentTypes {
-
-			fmt.Println("******* Found dependent type:", importPath, t.Sel.Name)
+		valueType, err := getDependentType(parseOpts, importPath, t.Sel.Name, true)
+		if err != nil {
+			return nil, err
+		}
 
-			dependentImports, depTypeExpr, err := fetchFileImportsAndAstForDependentType(importPath, t.Sel.Name)
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to get ast for dependent type")
-			}
+		return TypeNamed{
+			Name:   t.Sel.Name,
+			Import: importPath,
+			ValueType: valueType,
+		}, nil
 
+	// i.e. an instantiation of a generic type with a single type arg
+	//	`SomeType[int]`
+	case *ast.IndexExpr:
+		return getGenericType(parseOpts, imports, t.X, []ast.Expr{t.Index})
+
+	// i.e. an instantiation of a generic type with multiple type args
+	//	`SomeType[int, string]`
+	case *ast.IndexListExpr:
+		return getGenericType(parseOpts, imports, t.X, t.Indices)
+
+	// i.e. a union of types in a type param constraint
+	//	`int | float64`
+	case *ast.BinaryExpr:
+		if t.Op != token.OR {
+			return nil, errors.New("unknown binary expression in type '" + t.Op.String() + "'")
+		}
 
-			// TODO:
-			// * Stop parsing nested dependent types (need to change the parseOpts passed here to turn off dep types)
-			valueType, err = getFullType(parseOptions{}, dependentImports, depTypeExpr)
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to get full type for dependent type")
-			}
+		x, err := getFullType(parseOpts, imports, t.X)
+		if err != nil {
+			return nil, err
+		}
 
-		  //get dependent type:
-		 	//	Parse importPath with `packages`
-		 	//	look for type with `t.Sel.Name`
-		 	//	Parse ast of that type
+		y, err := getFullType(parseOpts, imports, t.Y)
+		if err != nil {
+			return nil, err
 		}
 
+		return TypeUnion{
+			Terms: append(unionTerms(x), unionTerms(y)...),
+		}, nil
 
-		//fmt.Println("****** Type:", importPath, importPrefix + "." + t.Sel.Name)
+	// i.e. an approximation element in a type param constraint
+	//	`~int`
+	case *ast.UnaryExpr:
+		if t.Op != token.TILDE {
+			return nil, errors.New("unknown unary expression in type '" + t.Op.String() + "'")
+		}
 
-		return TypeNamed{
-			Name:   t.Sel.Name,
-			Import: importPath,
-			ValueType: valueType,
+		x, err := getFullType(parseOpts, imports, t.X)
+		if err != nil {
+			return nil, err
+		}
+
+		return TypeUnion{
+			Terms: []TypeUnionTerm{
+				{
+					Type:  x,
+					Tilde: true,
+				},
+			},
 		}, nil
 
 	case *ast.StructType:
@@ -643,12 +1456,27 @@ func getFullType(
 			return nil, err
 		}
 
+		// There is one decl for each embedded field and for each name of
+		// each other field, in order
 		var s TypeStruct
-		for _, f := range structFieldsAndEmbeds {
-			if f.Name == "" {
-				s.Embeds = append(s.Embeds, f.Type)
-			} else {
+		iDecl := 0
+		for _, field := range t.Fields.List {
+			if len(field.Names) == 0 {
+				f := structFieldsAndEmbeds[iDecl]
+				s.Embeds = append(s.Embeds, StructEmbed{
+					Type:      f.Type,
+					DocString: f.DocString,
+					Comment:   f.Comment,
+				})
+				iDecl++
+				continue
+			}
+
+			for iName := range field.Names {
+				f := structFieldsAndEmbeds[iDecl]
+				f.SharesSpec = iName > 0
 				s.Fields = append(s.Fields, f)
+				iDecl++
 			}
 		}
 
@@ -712,55 +1540,97 @@ func getFullType(
 	}
 }
 
-func fetchFileImportsAndAstForDependentType(
-	pkgPath string,
-	typeName string,
-) (map[string]string, ast.Expr, error) {
+func getGenericType(
+	parseOpts parseOptions,
+	imports map[string]string,
+	x ast.Expr,
+	typeArgExprs []ast.Expr,
+) (Type, error) {
 
-	conf := &packages.Config{
-		Mode: packages.NeedDeps |
-			packages.NeedFiles |
-			packages.NeedName |
-			packages.NeedSyntax,
-	}
-	pkgs, err := packages.Load(conf, pkgPath)
+	baseType, err := getFullType(parseOpts, imports, x)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+
+	named, ok := baseType.(TypeNamed)
+	if !ok {
+		return nil, errors.New("expected named type for generic type instantiation")
 	}
 
-	if len(pkgs) == 0 {
-		return nil, nil, errors.New("package not found '" + pkgPath + "'")
+	typeArgs := make([]Type, 0, len(typeArgExprs))
+	for _, argExpr := range typeArgExprs {
+		arg, err := getFullType(parseOpts, imports, argExpr)
+		if err != nil {
+			return nil, err
+		}
+		typeArgs = append(typeArgs, arg)
 	}
 
-	for _, fileAst := range pkgs[0].Syntax {
-		for _, decl := range fileAst.Decls {
+	return TypeGeneric{
+		Name:      named.Name,
+		Import:    named.Import,
+		TypeArgs:  typeArgs,
+		ValueType: named.ValueType,
+	}, nil
+}
 
-			switch d := decl.(type) {
-			case *ast.GenDecl:
+// unionTerms returns the terms of `t` if it is a union, otherwise it returns
+// `t` as a single term (this is used to flatten nested unions)
+func unionTerms(t Type) []TypeUnionTerm {
 
-				for _, declSpec := range d.Specs {
+	if u, ok := t.(TypeUnion); ok {
+		return u.Terms
+	}
 
-					switch s := declSpec.(type) {
-					case *ast.TypeSpec:
+	return []TypeUnionTerm{
+		{
+			Type: t,
+		},
+	}
+}
 
-						if s.Name.Name == typeName {
+// dotImportedTypes returns a map of the names of all exported types declared
+// in dot imported packages to the import path of the package which declares
+// them.
+//
+// Resolving dot imported types requires loading the imported packages, which
+// are cached for the whole parse (see dependentTypeCache). The error from
+// loading a package is also cached, and the types of a package which cannot
+// be loaded are left out (so are left unqualified), unless
+// ParseStrictDotImports is set.
+func dotImportedTypes(
+	parseOpts parseOptions,
+	imports []ImportAndAlias,
+) (map[string]string, error) {
 
-							imports, err := parseImportsFromAstFile(fileAst)
-							if err != nil {
-								return nil, nil, err
-							}
+	var dotTypes map[string]string
+	for _, i := range imports {
+		if i.Alias != "." {
+			continue
+		}
 
-							fileImports := buildFileAliasesAndImports(pkgPath, imports)
+		pkg, err := parseOpts.dependentTypeCache.loadPackage(
+			i.Import,
+			parseOpts.loadDir,
+		)
+		if err != nil {
+			if parseOpts.strictDotImports {
+				return nil, errors.Wrap(err, "failed to load dot imported package")
+			}
+			continue
+		}
 
-							return fileImports, s.Type, nil
-						}
-					}
-				}
+		if dotTypes == nil {
+			dotTypes = make(map[string]string)
+		}
+		for name := range pkg.types {
+			if ast.IsExported(name) {
+				dotTypes[name] = i.Import
 			}
 		}
 	}
 
-	return nil, nil, errors.New("type '" + typeName + "' not found in packge '" + pkgPath + "'")
+	return dotTypes, nil
 }
 
 func removeQuotes(s string) string {
@@ -783,9 +1653,6 @@ func parseImportSpec(
 	if n.Name != nil {
 		alias = n.Name.String()
 	}
-	if alias == "." {
-		return ImportAndAlias{}, errors.New("'.' imports are not supported")
-	}
 
 	return ImportAndAlias{
 		Import: importPath,
@@ -796,15 +1663,28 @@ func parseImportSpec(
 func isBuiltInType(t string) bool {
 
 	builtInTypes := map[string]struct{}{
-		"bool":    {},
-		"byte":    {},
-		"error":   {},
-		"float32": {},
-		"float64": {},
-		"int":     {},
-		"int32":   {},
-		"int64":   {},
-		"string":  {},
+		"any":        {},
+		"bool":       {},
+		"byte":       {},
+		"comparable": {},
+		"complex64":  {},
+		"complex128": {},
+		"error":      {},
+		"float32":    {},
+		"float64":    {},
+		"int":        {},
+		"int8":       {},
+		"int16":      {},
+		"int32":      {},
+		"int64":      {},
+		"rune":       {},
+		"string":     {},
+		"uint":       {},
+		"uint8":      {},
+		"uint16":     {},
+		"uint32":     {},
+		"uint64":     {},
+		"uintptr":    {},
 	}
 
 	_, ok := builtInTypes[t]
@@ -814,10 +1694,18 @@ func isBuiltInType(t string) bool {
 func typeFromString(t string) Type {
 
 	switch t {
+	case "any":
+		return TypeAny{}
 	case "bool":
 		return TypeBool{}
 	case "byte":
 		return TypeByte{}
+	case "comparable":
+		return TypeComparable{}
+	case "complex64":
+		return TypeComplex64{}
+	case "complex128":
+		return TypeComplex128{}
 	case "error":
 		return TypeError{}
 	case "float32":
@@ -826,12 +1714,30 @@ func typeFromString(t string) Type {
 		return TypeFloat64{}
 	case "int":
 		return TypeInt{}
+	case "int8":
+		return TypeInt8{}
+	case "int16":
+		return TypeInt16{}
 	case "int32":
 		return TypeInt32{}
 	case "int64":
 		return TypeInt64{}
+	case "rune":
+		return TypeRune{}
 	case "string":
 		return TypeString{}
+	case "uint":
+		return TypeUint{}
+	case "uint8":
+		return TypeUint8{}
+	case "uint16":
+		return TypeUint16{}
+	case "uint32":
+		return TypeUint32{}
+	case "uint64":
+		return TypeUint64{}
+	case "uintptr":
+		return TypeUintptr{}
 	}
 	return nil
 }
@@ -839,7 +1745,7 @@ func typeFromString(t string) Type {
 func declVarsFromAstValueSpec(
 	parseOpts parseOptions,
 	imports map[string]string,
-	fp *os.File,
+	src []byte,
 	fileSet *token.FileSet,
 	spec *ast.ValueSpec,
 ) ([]DeclVar, error) {
@@ -855,37 +1761,46 @@ func declVarsFromAstValueSpec(
 		}
 	}
 
-	var docString string
-	if spec.Doc != nil {
-		var err error
-		docString, err = readFromFileSet(fp, fileSet, spec.Doc.Pos(), spec.Doc.End())
-		if err != nil {
-			return nil, err
-		}
+	docString, directives, err := docAndDirectives(src, fileSet, spec.Doc)
+	if err != nil {
+		return nil, err
 	}
 
 	var declVars []DeclVar
 	hasLiteralValues := (len(spec.Names) == len(spec.Values))
+
+	// e.g. `var a, b = strconv.Atoi("1")`
+	hasMultiValuedLiteral := len(spec.Values) == 1 && len(spec.Names) > 1
+
 	for iDecl := range spec.Names {
 
 		var literalValue string
-		if hasLiteralValues {
-			switch litVal := spec.Values[iDecl].(type) {
-			case *ast.BasicLit:
-				literalValue = litVal.Value
-			case *ast.Ident:
-				literalValue = litVal.String()
+		var literalValueImports map[string]bool
+		if hasLiteralValues || (hasMultiValuedLiteral && iDecl == 0) {
+			valueExpr := spec.Values[iDecl]
+
+			var err error
+			literalValue, err = readFromFileSet(src, fileSet, valueExpr.Pos(), valueExpr.End())
+			if err != nil {
+				return nil, err
 			}
+
+			literalValueImports = exprRequiredImports(imports, valueExpr)
 		}
 
 		declVars = append(
 			declVars,
 			DeclVar{
-				Name:         spec.Names[iDecl].String(),
-				Import:       parseOpts.pkgImportPath,
-				Type:         sType,
-				LiteralValue: literalValue,
-				DocString:    docString,
+				Name:                spec.Names[iDecl].String(),
+				Import:              parseOpts.pkgImportPath,
+				Type:                sType,
+				LiteralValue:        literalValue,
+				LiteralValueImports: literalValueImports,
+				SharesLiteralValue:  hasMultiValuedLiteral && iDecl > 0,
+				SharesSpec:          iDecl > 0,
+				DocString:           docString,
+				Directives:          directives,
+				Comment:             commentGroupText(spec.Comment),
 			},
 		)
 	}
@@ -897,7 +1812,76 @@ type ParseOption func(parseOptions) parseOptions
 
 type parseOptions struct {
 	pkgImportPath string
-	dependentTypes bool
+	excludeTests bool
+	typeChecked bool
+
+	// primaryPackage is the name of the package which is given pkgImportPath
+	// (see primaryPackageName); empty if every package is (this is internal
+	// state, not a user option)
+	primaryPackage string
+
+	// strictDotImports returns an error for a dot import which cannot be
+	// loaded, rather than leaving its identifiers unresolved
+	strictDotImports bool
+
+	// dependentTypesDepth is the number of levels of dependent types to
+	// resolve; 0 disables resolving dependent types and a negative depth is
+	// unlimited
+	dependentTypesDepth int
+
+	// buildContext is used to select which files are parsed; if nil then all
+	// files are parsed
+	buildContext *build.Context
+
+	// fsys is the filesystem which source files are read from; if nil then
+	// files are read from the OS filesystem
+	fsys fs.FS
+
+	// typeParams is the set of type param names in scope for the declaration
+	// currently being parsed (this is internal state, not a user option)
+	typeParams map[string]bool
+
+	// dependentTypeCache holds the packages loaded to resolve dependent types
+	// and dot imports, and is shared by every file (and package) in a parse
+	// (this is internal state, not a user option)
+	dependentTypeCache *dependentTypeCache
+
+	// loadDir is the directory which the packages imported by the file
+	// currently being parsed are loaded from; empty to use the working
+	// directory (this is internal state, not a user option)
+	loadDir string
+
+	// typeInfo is the type information of the package being parsed when
+	// parsing with ParseTypeChecked (this is internal state, not a user
+	// option)
+	typeInfo *typeCheckedPackage
+
+	// fileSet and fileObjects are the file set and the type checked objects
+	// (see typeCheckedPackage.objects) of the file currently being parsed
+	// (this is internal state, not a user option)
+	fileSet     *token.FileSet
+	fileObjects map[int]types.Object
+
+	// dotImportTypes maps the names of types from dot imported packages to
+	// their import path for the file currently being parsed (this is internal
+	// state, not a user option)
+	dotImportTypes map[string]string
+}
+
+// withLoadDir sets the directory which the packages imported by `filename`
+// are loaded from to the directory of `filename`, if it is a directory on the
+// OS filesystem
+func (o parseOptions) withLoadDir(filename string) parseOptions {
+
+	if o.fsys != nil {
+		return o
+	}
+
+	dir := filepath.Dir(filename)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		o.loadDir = dir
+	}
+	return o
 }
 
 func ParseWithPkgImportPath(importPath string) ParseOption {
@@ -907,9 +1891,279 @@ func ParseWithPkgImportPath(importPath string) ParseOption {
 	}
 }
 
+// ParseDependentTypes resolves the ValueType of each TypeNamed found in the
+// parsed declarations, from the declaration of the type in its own package or
+// the current package
+//
+// Named types within the resolved ValueTypes are not themselves resolved; use
+// ParseDependentTypesDepth to resolve them recursively.
+//
+// Each package is only loaded once per parse.
 func ParseDependentTypes() ParseOption {
+	return ParseDependentTypesDepth(1)
+}
+
+// ParseDependentTypesDepth resolves dependent types (see ParseDependentTypes)
+// recursively, up to `depth` levels of named types
+//
+// A negative `depth` is unlimited. A recursive type (e.g. a linked list node
+// with a `Next *Node` field) is not resolved again within its own ValueType,
+// so is left with a nil ValueType at that point.
+func ParseDependentTypesDepth(depth int) ParseOption {
+	return func(o parseOptions) parseOptions {
+		o.dependentTypesDepth = depth
+		return o
+	}
+}
+
+// withDependentTypeCache shares `cache` between parses (e.g. of each package
+// in ParsePackages)
+func withDependentTypeCache(cache *dependentTypeCache) ParseOption {
+	return func(o parseOptions) parseOptions {
+		o.dependentTypeCache = cache
+		return o
+	}
+}
+
+// ParseTypeChecked loads the package being parsed with full type information
+// (using `golang.org/x/tools/go/packages`) and uses it to resolve every named
+// type to the package which declares it
+//
+// Each TypeNamed found in the parsed declarations then also has its
+// underlying type as its ValueType (so whether it is a struct, interface,
+// basic type etc. is known) and the methods declared on it. Named types
+// within a ValueType are not themselves expanded.
+//
+// The package must type check without errors. This option cannot be used
+// with ParseFromFS or ParseSources.
+func ParseTypeChecked() ParseOption {
+	return func(o parseOptions) parseOptions {
+		o.typeChecked = true
+		return o
+	}
+}
+
+// ParseStrictDotImports returns an error if a dot imported package cannot be
+// loaded
+//
+// By default, the identifiers of a dot imported package which cannot be
+// loaded (e.g. one which is not in the module's dependencies) are left
+// unresolved; i.e. they are parsed as types declared in the current package.
+func ParseStrictDotImports() ParseOption {
+	return func(o parseOptions) parseOptions {
+		o.strictDotImports = true
+		return o
+	}
+}
+
+// ParseExcludeTests excludes `_test.go` files (and so any external test
+// package) when parsing a package
+func ParseExcludeTests() ParseOption {
+	return func(o parseOptions) parseOptions {
+		o.excludeTests = true
+		return o
+	}
+}
+
+// ParseWithBuildContext only parses the files which would be included when
+// building with `ctxt`, based on their filenames (e.g. `_linux.go`) and
+// build constraints
+//
+// e.g. to parse the files for linux/arm64 with the tag `mytag`:
+//
+//	ctxt := build.Default
+//	ctxt.GOOS = "linux"
+//	ctxt.GOARCH = "arm64"
+//	ctxt.BuildTags = []string{"mytag"}
+//	gopkg.Parse(dir, gopkg.ParseWithBuildContext(ctxt))
+func ParseWithBuildContext(ctxt build.Context) ParseOption {
+	return func(o parseOptions) parseOptions {
+		o.buildContext = &ctxt
+		return o
+	}
+}
+
+// ParseFromFS makes Parse read source files from `fsys` rather than the OS
+// filesystem (e.g. from an `embed.FS` of fixture packages)
+//
+// Paths passed to Parse are then paths within `fsys` (see `fs.ValidPath`).
+// The package import path cannot be detected from an `fs.FS`, so it must also
+// be set with ParseWithPkgImportPath.
+func ParseFromFS(fsys fs.FS) ParseOption {
 	return func(o parseOptions) parseOptions {
-		o.dependentTypes = true
+		o.fsys = fsys
 		return o
 	}
 }
+
+func (o parseOptions) stat(name string) (fs.FileInfo, error) {
+	if o.fsys != nil {
+		return fs.Stat(o.fsys, name)
+	}
+	return os.Stat(name)
+}
+
+func (o parseOptions) readDir(name string) ([]fs.DirEntry, error) {
+	if o.fsys != nil {
+		return fs.ReadDir(o.fsys, name)
+	}
+	return os.ReadDir(name)
+}
+
+func (o parseOptions) readFile(name string) ([]byte, error) {
+	if o.fsys != nil {
+		return fs.ReadFile(o.fsys, name)
+	}
+	return os.ReadFile(name)
+}
+
+// matchBuildContext returns true if the file at `filename`, with source
+// `src`, should be parsed with the build context set by ParseWithBuildContext
+// (always true if no build context was set)
+func (o parseOptions) matchBuildContext(
+	filename string,
+	src []byte,
+) (bool, error) {
+
+	if o.buildContext == nil {
+		return true, nil
+	}
+
+	ctxt := *o.buildContext
+	ctxt.OpenFile = func(string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(src)), nil
+	}
+
+	dir, name := filepath.Split(filename)
+	return ctxt.MatchFile(dir, name)
+}
+
+func (o parseOptions) dir(name string) string {
+	if o.fsys != nil {
+		return path.Dir(name)
+	}
+	return filepath.Dir(name)
+}
+
+func (o parseOptions) base(name string) string {
+	if o.fsys != nil {
+		return path.Base(name)
+	}
+	return filepath.Base(name)
+}
+
+func (o parseOptions) joinPath(dir string, name string) string {
+	if o.fsys != nil {
+		return path.Join(dir, name)
+	}
+	return filepath.Join(dir, name)
+}
+
+// buildConstraintFromAstFile returns the expression of the build constraint
+// before the package clause of `f`
+//
+// A `//go:build` line takes precedence; otherwise any legacy `// +build`
+// lines are combined into a single expression. Returns an empty string if the
+// file has no build constraints.
+func buildConstraintFromAstFile(f *ast.File) (string, error) {
+
+	var plusBuild constraint.Expr
+	for _, cg := range f.Comments {
+		if cg.Pos() > f.Package {
+			break
+		}
+
+		// Build constraints must be followed by a blank line, so are never
+		// part of the package doc comment
+		if cg == f.Doc {
+			continue
+		}
+
+		for _, c := range cg.List {
+			if constraint.IsGoBuild(c.Text) {
+				expr, err := constraint.Parse(c.Text)
+				if err != nil {
+					return "", err
+				}
+				return expr.String(), nil
+			}
+
+			if constraint.IsPlusBuild(c.Text) {
+				expr, err := constraint.Parse(c.Text)
+				if err != nil {
+					return "", err
+				}
+				if plusBuild == nil {
+					plusBuild = expr
+				} else {
+					plusBuild = &constraint.AndExpr{X: plusBuild, Y: expr}
+				}
+			}
+		}
+	}
+
+	if plusBuild == nil {
+		return "", nil
+	}
+	return plusBuild.String(), nil
+}
+
+// exprRequiredImports returns the set of imports referenced by package
+// qualified identifiers (e.g. `time.Second`) anywhere within `expr`.
+//
+// Returns nil if no imports are referenced.
+func exprRequiredImports(
+	imports map[string]string,
+	expr ast.Expr,
+) map[string]bool {
+
+	var ret map[string]bool
+	for _, importPath := range exprImportQualifiers(imports, expr) {
+		if ret == nil {
+			ret = make(map[string]bool)
+		}
+		ret[importPath] = true
+	}
+	return ret
+}
+
+// exprImportQualifiers returns the import path of each package qualifier
+// (e.g. `time` in `time.Second`) used anywhere within `expr`, by qualifier
+//
+// Returns nil if no imports are referenced.
+func exprImportQualifiers(
+	imports map[string]string,
+	expr ast.Expr,
+) map[string]string {
+
+	var ret map[string]string
+	ast.Inspect(expr, func(n ast.Node) bool {
+
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		x, ok := sel.X.(*ast.Ident)
+		// Identifiers declared within the file (e.g. a local var within a
+		// func literal) are resolved by the parser, so any with a non-nil
+		// `Obj` cannot be an import
+		if !ok || x.Obj != nil {
+			return true
+		}
+
+		importPath, ok := imports[x.Name]
+		if !ok || x.Name == CURRENT_PKG {
+			return true
+		}
+
+		if ret == nil {
+			ret = make(map[string]string)
+		}
+		ret[x.Name] = importPath
+
+		return true
+	})
+
+	return ret
+}
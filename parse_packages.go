@@ -0,0 +1,188 @@
+package gopkg
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ParsePackages parses all packages matching the go package `patterns` and
+// returns their files grouped by package import path
+//
+// Patterns are relative or absolute directory paths, optionally ending with
+// `/...` to match the directory and all directories below it (e.g. `./...`
+// or `./internal/...`). As with the go tool, `testdata` and `vendor`
+// directories, directories beginning with `.` or `_` and nested modules are
+// not matched by `...` and directories with no `.go` files are skipped.
+//
+// The import path of each package is found with PackageImportPath, so any
+// ParseWithPkgImportPath option is ignored. A package which is not the
+// directory's own package (nor its external test package), such as a
+// `//go:build ignore` generator in `package main`, is grouped separately
+// under the key `importPath#pkgName` (see FileContents.PackageImportPath).
+func ParsePackages(
+	patterns []string,
+	opts ...ParseOption,
+) (map[string][]FileContents, error) {
+
+	dirs, err := matchPackageDirs(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	// Share loaded dependent packages between every package parsed
+	opts = append(opts, withDependentTypeCache(newDependentTypeCache()))
+
+	pkgs := make(map[string][]FileContents)
+	for _, dir := range dirs {
+
+		importPath, err := PackageImportPath(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		pkgFiles, err := Parse(
+			dir,
+			append(opts, ParseWithPkgImportPath(importPath))...,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range pkgFiles {
+			pkgs[f.PackageImportPath] = append(pkgs[f.PackageImportPath], f)
+		}
+	}
+
+	return pkgs, nil
+}
+
+// matchPackageDirs returns the sorted list of directories which contain `.go`
+// files and match any of `patterns`
+func matchPackageDirs(patterns []string) ([]string, error) {
+
+	matched := make(map[string]bool)
+	for _, pattern := range patterns {
+
+		if pattern == "" {
+			return nil, errors.New("package pattern cannot be empty")
+		}
+
+		if !strings.HasPrefix(pattern, ".") && !filepath.IsAbs(pattern) {
+			return nil, errors.New(
+				"unsupported package pattern `" + pattern +
+					"` - only relative (e.g. `./...`) or absolute directory patterns are supported",
+			)
+		}
+
+		recursive := strings.HasSuffix(pattern, "...")
+		if !recursive {
+			hasGoFiles, err := dirHasGoFiles(pattern)
+			if err != nil {
+				return nil, err
+			}
+			if hasGoFiles {
+				matched[filepath.Clean(pattern)] = true
+			}
+			continue
+		}
+
+		// As with the go tool, the pattern matches any directory whose path
+		// starts with the pattern before the `...`; e.g. `./foo...` matches
+		// both `./foo/bar` and `./foobar`, whereas `./foo/...` only matches
+		// `./foo` and the directories within it
+		prefix := strings.TrimSuffix(pattern, "...")
+		root := filepath.Clean(prefix)
+		matchPrefix := ""
+		if prefix != "" &&
+			!strings.HasSuffix(prefix, "/") &&
+			!strings.HasSuffix(prefix, string(filepath.Separator)) {
+
+			matchPrefix = root
+			root = filepath.Dir(root)
+		}
+
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() {
+				return nil
+			}
+
+			if path != root {
+				if skipPackageDir(path, d.Name()) {
+					return filepath.SkipDir
+				}
+			}
+
+			if !strings.HasPrefix(path, matchPrefix) {
+				// Only walk into the directories which can contain a match
+				if path != root &&
+					!strings.HasPrefix(matchPrefix, path+string(filepath.Separator)) {
+
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			hasGoFiles, err := dirHasGoFiles(path)
+			if err != nil {
+				return err
+			}
+			if hasGoFiles {
+				matched[path] = true
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dirs := make([]string, 0, len(matched))
+	for dir := range matched {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	return dirs, nil
+}
+
+// skipPackageDir returns true if the go tool would not match the directory
+// `path` (with base name `name`) with a `...` pattern
+func skipPackageDir(path string, name string) bool {
+
+	if name == "testdata" || name == "vendor" {
+		return true
+	}
+
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return true
+	}
+
+	// Nested modules are not part of the module being matched
+	_, err := os.Stat(filepath.Join(path, "go.mod"))
+	return err == nil
+}
+
+func dirHasGoFiles(dir string) (bool, error) {
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".go") {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,113 @@
+package gopkg_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/thecodedproject/gopkg"
+)
+
+func TestParsePackages(t *testing.T) {
+
+	const nestedPkgs = "github.com/thecodedproject/gopkg/test_packages/nested_packages"
+	const multiplePkgs = "github.com/thecodedproject/gopkg/test_packages/multiple_packages"
+
+	testCases := []struct {
+		Name          string
+		Patterns      []string
+		ExpectedFiles map[string][]string
+		ExpectedErr   string
+	}{
+		{
+			Name:        "empty pattern returns error",
+			Patterns:    []string{""},
+			ExpectedErr: "package pattern cannot be empty",
+		},
+		{
+			Name:        "import path pattern returns error",
+			Patterns:    []string{"github.com/thecodedproject/gopkg/..."},
+			ExpectedErr: "unsupported package pattern `github.com/thecodedproject/gopkg/...` - only relative (e.g. `./...`) or absolute directory patterns are supported",
+		},
+		{
+			Name:     "single directory pattern",
+			Patterns: []string{"./test_packages/nested_packages/sub"},
+			ExpectedFiles: map[string][]string{
+				nestedPkgs + "/sub": {"test_packages/nested_packages/sub/sub.go"},
+			},
+		},
+		{
+			Name:     "recursive pattern skips testdata, vendor, hidden and nested module dirs",
+			Patterns: []string{"./test_packages/nested_packages/..."},
+			ExpectedFiles: map[string][]string{
+				nestedPkgs:                 {"test_packages/nested_packages/root.go"},
+				nestedPkgs + "/sub":        {"test_packages/nested_packages/sub/sub.go"},
+				nestedPkgs + "/sub/deeper": {"test_packages/nested_packages/sub/deeper/deeper.go"},
+				nestedPkgs + "/subway":     {"test_packages/nested_packages/subway/subway.go"},
+			},
+		},
+		{
+			Name:     "recursive pattern of a directory does not match sibling directories",
+			Patterns: []string{"./test_packages/nested_packages/sub/..."},
+			ExpectedFiles: map[string][]string{
+				nestedPkgs + "/sub":        {"test_packages/nested_packages/sub/sub.go"},
+				nestedPkgs + "/sub/deeper": {"test_packages/nested_packages/sub/deeper/deeper.go"},
+			},
+		},
+		{
+			Name:     "recursive pattern of a path prefix matches sibling directories",
+			Patterns: []string{"./test_packages/nested_packages/sub..."},
+			ExpectedFiles: map[string][]string{
+				nestedPkgs + "/sub":        {"test_packages/nested_packages/sub/sub.go"},
+				nestedPkgs + "/sub/deeper": {"test_packages/nested_packages/sub/deeper/deeper.go"},
+				nestedPkgs + "/subway":     {"test_packages/nested_packages/subway/subway.go"},
+			},
+		},
+		{
+			Name:     "other packages in a directory are grouped separately",
+			Patterns: []string{"./test_packages/multiple_packages"},
+			ExpectedFiles: map[string][]string{
+				multiplePkgs:           {"test_packages/multiple_packages/shapes.go"},
+				multiplePkgs + "_test": {"test_packages/multiple_packages/shapes_test.go"},
+				multiplePkgs + "#main": {"test_packages/multiple_packages/gen.go"},
+			},
+		},
+		{
+			Name: "overlapping patterns parse each package once",
+			Patterns: []string{
+				"./test_packages/nested_packages/sub/...",
+				"./test_packages/nested_packages/sub/deeper",
+			},
+			ExpectedFiles: map[string][]string{
+				nestedPkgs + "/sub":        {"test_packages/nested_packages/sub/sub.go"},
+				nestedPkgs + "/sub/deeper": {"test_packages/nested_packages/sub/deeper/deeper.go"},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			pkgs, err := gopkg.ParsePackages(test.Patterns)
+
+			if test.ExpectedErr != "" {
+				require.EqualError(t, err, test.ExpectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			actualFiles := make(map[string][]string)
+			for importPath, files := range pkgs {
+				for _, f := range files {
+					require.Equal(t, importPath, f.PackageImportPath)
+					actualFiles[importPath] = append(actualFiles[importPath], f.Filepath)
+				}
+				sort.Strings(actualFiles[importPath])
+			}
+
+			require.Equal(t, test.ExpectedFiles, actualFiles)
+		})
+	}
+}
@@ -2,7 +2,14 @@ package gopkg_test
 
 import (
 	"bytes"
+	"embed"
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/sebdah/goldie/v2"
 	"github.com/stretchr/testify/assert"
@@ -109,10 +116,12 @@ func TestParseDir(t *testing.T) {
 							Import:       "some/import/all_built_in_types",
 							Type:         gopkg.TypeUnnamedLiteral{},
 							LiteralValue: "\"other val\"",
+							SharesSpec:   true,
 						},
 						{
 							Name:         "RealNumberConst",
 							Import:       "some/import/all_built_in_types",
+							Iota:         1,
 							Type:         gopkg.TypeFloat64{},
 							LiteralValue: "1.234",
 						},
@@ -129,6 +138,7 @@ func TestParseDir(t *testing.T) {
 							Import:       "some/import/all_built_in_types",
 							Type:         gopkg.TypeInt{},
 							LiteralValue: "2",
+							SharesSpec:   true,
 						},
 						{
 							Name:   "SomeFloat",
@@ -218,6 +228,58 @@ func TestParseDir(t *testing.T) {
 						},
 					},
 				},
+				{
+					Filepath:          "test_packages/all_built_in_types/other_predeclared_types.go",
+					PackageName:       "all_built_in_types",
+					PackageImportPath: "some/import/all_built_in_types",
+					Vars: []gopkg.DeclVar{
+						{
+							Name:         "SomeUint64",
+							Import:       "some/import/all_built_in_types",
+							Type:         gopkg.TypeUint64{},
+							LiteralValue: "10",
+						},
+					},
+					Functions: []gopkg.DeclFunc{
+						{
+							Name:   "SomeOtherFunc",
+							Import: "some/import/all_built_in_types",
+							Args: []gopkg.DeclVar{
+								{
+									Name: "a",
+									Type: gopkg.TypeAny{},
+								},
+							},
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypeRune{},
+								gopkg.TypeComplex128{},
+							),
+							BodyTmpl: "\n\n\treturn 0, 0\n",
+						},
+					},
+					Types: []gopkg.DeclType{
+						{
+							Name:   "SomeOtherStruct",
+							Import: "some/import/all_built_in_types",
+							Type: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{Name: "SI8", Type: gopkg.TypeInt8{}},
+									{Name: "SI16", Type: gopkg.TypeInt16{}},
+									{Name: "U", Type: gopkg.TypeUint{}},
+									{Name: "U8", Type: gopkg.TypeUint8{}},
+									{Name: "U16", Type: gopkg.TypeUint16{}},
+									{Name: "U32", Type: gopkg.TypeUint32{}},
+									{Name: "U64", Type: gopkg.TypeUint64{}},
+									{Name: "UP", Type: gopkg.TypeUintptr{}},
+									{Name: "R", Type: gopkg.TypeRune{}},
+									{Name: "C64", Type: gopkg.TypeComplex64{}},
+									{Name: "C128", Type: gopkg.TypeComplex128{}},
+									{Name: "A", Type: gopkg.TypeAny{}},
+								},
+							},
+						},
+					},
+				},
 			},
 		},
 		{
@@ -263,6 +325,24 @@ func TestParseDir(t *testing.T) {
 									ValueType: gopkg.TypeNamed{
 										Name:   "SomeArrayStruct",
 										Import: "some/import/composite_types",
+										ValueType: gopkg.TypeStruct{
+											Fields: []gopkg.DeclVar{
+												{
+													Name: "AOfInts",
+													Type: gopkg.TypeArray{
+														ValueType: gopkg.TypeInt64{},
+													},
+												},
+												{
+													Name: "AOfPToStrings",
+													Type: gopkg.TypeArray{
+														ValueType: gopkg.TypePointer{
+															ValueType: gopkg.TypeString{},
+														},
+													},
+												},
+											},
+										},
 									},
 								},
 							),
@@ -325,6 +405,203 @@ func TestParseDir(t *testing.T) {
 						},
 					},
 				},
+				{
+					Filepath:          "test_packages/composite_types/channels.go",
+					PackageName:       "composite_types",
+					PackageImportPath: "some/import/composite_types",
+					Imports:           tmpl.UnnamedImports("context"),
+					Functions: []gopkg.DeclFunc{
+						{
+							Name:   "SomeChanFunc",
+							Import: "some/import/composite_types",
+							Args: []gopkg.DeclVar{
+								{
+									Name: "a",
+									Type: gopkg.TypeChan{
+										ValueType: gopkg.TypeChan{
+											Dir:       gopkg.ChanDirRecv,
+											ValueType: gopkg.TypeBool{},
+										},
+									},
+								},
+							},
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypeChan{
+									ValueType: gopkg.TypePointer{
+										ValueType: gopkg.TypeNamed{
+											Name:   "SomeChanStruct",
+											Import: "some/import/composite_types",
+											ValueType: gopkg.TypeStruct{
+												Fields: []gopkg.DeclVar{
+													{
+														Name: "Done",
+														Type: gopkg.TypeChan{
+															ValueType: gopkg.TypeStruct{},
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							),
+							BodyTmpl: "\n\n\treturn nil\n",
+						},
+					},
+					Types: []gopkg.DeclType{
+						{
+							Name:   "MyCustomChan",
+							Import: "some/import/composite_types",
+							Type: gopkg.TypeChan{
+								Dir: gopkg.ChanDirSend,
+								ValueType: gopkg.TypeChan{
+									Dir:       gopkg.ChanDirRecv,
+									ValueType: gopkg.TypeInt{},
+								},
+							},
+						},
+						{
+							Name:   "SomeChanInterface",
+							Import: "some/import/composite_types",
+							Type: gopkg.TypeInterface{
+								Funcs: []gopkg.DeclFunc{
+									{
+										Name: "Jobs",
+										ReturnArgs: tmpl.UnnamedReturnArgs(
+											gopkg.TypeChan{
+												Dir: gopkg.ChanDirRecv,
+												ValueType: gopkg.TypeNamed{
+													Name:   "Context",
+													Import: "context",
+												},
+											},
+										),
+									},
+									{
+										Name: "Results",
+										Args: []gopkg.DeclVar{
+											{
+												Name: "r",
+												Type: gopkg.TypeChan{
+													Dir:       gopkg.ChanDirSend,
+													ValueType: gopkg.TypeString{},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+						{
+							Name:   "SomeChanStruct",
+							Import: "some/import/composite_types",
+							Type: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{
+										Name: "Done",
+										Type: gopkg.TypeChan{
+											ValueType: gopkg.TypeStruct{},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Filepath:          "test_packages/composite_types/fixed_length_arrays.go",
+					PackageName:       "composite_types",
+					PackageImportPath: "some/import/composite_types",
+					Imports: []gopkg.ImportAndAlias{
+						{
+							Import: "crypto/sha256",
+						},
+					},
+					Consts: []gopkg.DeclVar{
+						{
+							Name:         "HashLen",
+							Import:       "some/import/composite_types",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "32",
+						},
+					},
+					Functions: []gopkg.DeclFunc{
+						{
+							Name:   "SomeFixedArrayFunc",
+							Import: "some/import/composite_types",
+							Args: []gopkg.DeclVar{
+								{
+									Name: "h",
+									Type: gopkg.TypeFixedArray{
+										Len:       "HashLen",
+										ValueType: gopkg.TypeByte{},
+									},
+								},
+							},
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypeFixedArray{
+									Len: "8",
+									ValueType: gopkg.TypeNamed{
+										Name:   "Hash",
+										Import: "some/import/composite_types",
+										ValueType: gopkg.TypeFixedArray{
+											Len:       "HashLen",
+											ValueType: gopkg.TypeByte{},
+										},
+									},
+								},
+							),
+							BodyTmpl: "\n\n\treturn [...]Hash{{}, {}, {}, {}, {}, {}, {}, {}}\n",
+						},
+					},
+					Types: []gopkg.DeclType{
+						{
+							Name:   "Hash",
+							Import: "some/import/composite_types",
+							Type: gopkg.TypeFixedArray{
+								Len:       "HashLen",
+								ValueType: gopkg.TypeByte{},
+							},
+						},
+						{
+							Name:   "SomeFixedArrayStruct",
+							Import: "some/import/composite_types",
+							Type: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{
+										Name: "Checksum",
+										Type: gopkg.TypeFixedArray{
+											Len:       "4",
+											ValueType: gopkg.TypeByte{},
+										},
+									},
+									{
+										Name: "Grid",
+										Type: gopkg.TypeFixedArray{
+											Len: "2 * HashLen",
+											ValueType: gopkg.TypeFixedArray{
+												Len: "3",
+												ValueType: gopkg.TypePointer{
+													ValueType: gopkg.TypeFloat64{},
+												},
+											},
+										},
+									},
+									{
+										Name: "Digest",
+										Type: gopkg.TypeFixedArray{
+											Len: "sha256.Size",
+											LenImports: map[string]string{
+												"sha256": "crypto/sha256",
+											},
+											ValueType: gopkg.TypeByte{},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
 				{
 					Filepath:          "test_packages/composite_types/function_types.go",
 					PackageName:       "composite_types",
@@ -455,6 +732,11 @@ func TestParseDir(t *testing.T) {
 													Type: gopkg.TypeNamed{
 														Name: "SomeType",
 														Import: "some/import/composite_types",
+														ValueType: gopkg.TypeFunc{
+															ReturnArgs: tmpl.UnnamedReturnArgs(
+																gopkg.TypeError{},
+															),
+														},
 													},
 												},
 											},
@@ -532,6 +814,38 @@ func TestParseDir(t *testing.T) {
 									ValueType: gopkg.TypeNamed{
 										Name:   "SomeMapStruct",
 										Import: "some/import/composite_types",
+										ValueType: gopkg.TypeStruct{
+											Fields: []gopkg.DeclVar{
+												{
+													Name: "MOfInts",
+													Type: gopkg.TypeMap{
+														KeyType:   gopkg.TypeInt64{},
+														ValueType: gopkg.TypeInt64{},
+													},
+												},
+												{
+													Name: "MOfPToStrings",
+													Type: gopkg.TypeMap{
+														KeyType: gopkg.TypeString{},
+														ValueType: gopkg.TypePointer{
+															ValueType: gopkg.TypeString{},
+														},
+													},
+												},
+												{
+													Name: "MOfDToArrayOfInt",
+													Type: gopkg.TypeMap{
+														KeyType: gopkg.TypeNamed{
+															Name:   "Decimal",
+															Import: "github.com/shopspring/decimal",
+														},
+														ValueType: gopkg.TypeArray{
+															ValueType: gopkg.TypeInt32{},
+														},
+													},
+												},
+											},
+										},
 									},
 								},
 							),
@@ -627,6 +941,16 @@ func TestParseDir(t *testing.T) {
 										ValueType: gopkg.TypeNamed{
 											Name:   "SomePointerStruct",
 											Import: "some/import/composite_types",
+											ValueType: gopkg.TypeStruct{
+												Fields: []gopkg.DeclVar{
+													{
+														Name: "PToInt",
+														Type: gopkg.TypePointer{
+															ValueType: gopkg.TypeInt32{},
+														},
+													},
+												},
+											},
 										},
 									},
 								},
@@ -707,7 +1031,7 @@ func TestParseDir(t *testing.T) {
 			},
 			Expected: []gopkg.FileContents{
 				{
-					Filepath:          "test_packages/custom_types/embedded_types.go",
+					Filepath:          "test_packages/custom_types/aliases.go",
 					PackageName:       "custom_types",
 					PackageImportPath: "some/import/custom_types",
 					Imports: []gopkg.ImportAndAlias{
@@ -716,15 +1040,75 @@ func TestParseDir(t *testing.T) {
 							Alias: "c",
 						},
 					},
+					Types: []gopkg.DeclType{
+						{
+							Name:   "OldContext",
+							Import: "some/import/custom_types",
+							Type: gopkg.TypeNamed{
+								Name:   "Context",
+								Import: "context",
+							},
+							IsAlias:   true,
+							DocString: "// OldContext is kept for backwards compatibility",
+						},
+						{
+							Name:   "DefinedContext",
+							Import: "some/import/custom_types",
+							Type: gopkg.TypeNamed{
+								Name:   "Context",
+								Import: "context",
+							},
+						},
+						{
+							Name:    "IntAlias",
+							Import:  "some/import/custom_types",
+							Type:    gopkg.TypeInt{},
+							IsAlias: true,
+						},
+						{
+							Name:   "EmbedAlias",
+							Import: "some/import/custom_types",
+							Type: gopkg.TypePointer{
+								ValueType: gopkg.TypeNamed{
+									Name:   "SingleEmbed",
+									Import: "some/import/custom_types",
+									ValueType: gopkg.TypeStruct{
+										Embeds: []gopkg.StructEmbed{
+											{
+												Type: gopkg.TypeNamed{
+													Name:   "Context",
+													Import: "context",
+												},
+											},
+										},
+									},
+								},
+							},
+							IsAlias: true,
+						},
+					},
+				},
+				{
+					Filepath:          "test_packages/custom_types/embedded_types.go",
+					PackageName:       "custom_types",
+					PackageImportPath: "some/import/custom_types",
+					Imports: []gopkg.ImportAndAlias{
+						{
+							Import: "context",
+							Alias:  "c",
+						},
+					},
 					Types: []gopkg.DeclType{
 						{
 							Name:   "SingleEmbed",
 							Import: "some/import/custom_types",
 							Type: gopkg.TypeStruct{
-								Embeds: []gopkg.Type{
-									gopkg.TypeNamed{
-										Name:   "Context",
-										Import: "context",
+								Embeds: []gopkg.StructEmbed{
+									{
+										Type: gopkg.TypeNamed{
+											Name:   "Context",
+											Import: "context",
+										},
 									},
 								},
 							},
@@ -745,13 +1129,15 @@ func TestParseDir(t *testing.T) {
 							Name:   "ManyEmbeds",
 							Import: "some/import/custom_types",
 							Type: gopkg.TypeStruct{
-								Embeds: []gopkg.Type{
-									gopkg.TypeError{},
-									gopkg.TypeNamed{
-										Name:   "Context",
-										Import: "context",
+								Embeds: []gopkg.StructEmbed{
+									{Type: gopkg.TypeError{}},
+									{
+										Type: gopkg.TypeNamed{
+											Name:   "Context",
+											Import: "context",
+										},
 									},
-									gopkg.TypeInt32{},
+									{Type: gopkg.TypeInt32{}},
 								},
 								Fields: []gopkg.DeclVar{
 									{
@@ -769,10 +1155,30 @@ func TestParseDir(t *testing.T) {
 									gopkg.TypeNamed{
 										Name:   "SingleEmbed",
 										Import: "some/import/custom_types",
+										ValueType: gopkg.TypeStruct{
+											Embeds: []gopkg.StructEmbed{
+												{
+													Type: gopkg.TypeNamed{
+														Name:   "Context",
+														Import: "context",
+													},
+												},
+											},
+										},
 									},
 									gopkg.TypeNamed{
 										Name:   "InterfaceEmbed",
 										Import: "some/import/custom_types",
+										ValueType: gopkg.TypeInterface{
+											Embeds: []gopkg.Type{
+												gopkg.TypeFloat64{},
+											},
+											Funcs: []gopkg.DeclFunc{
+												{
+													Name: "MyFunc",
+												},
+											},
+										},
 									},
 									gopkg.TypeNamed{
 										Name:   "Context",
@@ -818,8 +1224,34 @@ func TestParseDir(t *testing.T) {
 										ValueType: gopkg.TypeNamed{
 											Name:   "IntAsString",
 											Import: "some/import/proto_conversion",
-										},
-									},
+											ValueType: gopkg.TypeStruct{
+												Fields: []gopkg.DeclVar{
+													{
+														Name:      "Value",
+														Type:      gopkg.TypeString{},
+														StructTag: "protobuf:\"bytes,1,opt,name=value,proto3\" json:\"value,omitempty\"",
+													},
+													{
+														Name:      "XXX_NoUnkeyedLiteral",
+														Type:      gopkg.TypeStruct{},
+														StructTag: "json:\"-\"",
+													},
+													{
+														Name: "XXX_unrecognized",
+														Type: gopkg.TypeArray{
+															ValueType: gopkg.TypeByte{},
+														},
+														StructTag: "json:\"-\"",
+													},
+													{
+														Name:      "XXX_sizecache",
+														Type:      gopkg.TypeInt32{},
+														StructTag: "json:\"-\"",
+													},
+												},
+											},
+										},
+									},
 								},
 							},
 							ReturnArgs: tmpl.UnnamedReturnArgs(
@@ -842,6 +1274,32 @@ func TestParseDir(t *testing.T) {
 									ValueType: gopkg.TypeNamed{
 										Name:   "IntAsString",
 										Import: "some/import/proto_conversion",
+										ValueType: gopkg.TypeStruct{
+											Fields: []gopkg.DeclVar{
+												{
+													Name:      "Value",
+													Type:      gopkg.TypeString{},
+													StructTag: "protobuf:\"bytes,1,opt,name=value,proto3\" json:\"value,omitempty\"",
+												},
+												{
+													Name:      "XXX_NoUnkeyedLiteral",
+													Type:      gopkg.TypeStruct{},
+													StructTag: "json:\"-\"",
+												},
+												{
+													Name: "XXX_unrecognized",
+													Type: gopkg.TypeArray{
+														ValueType: gopkg.TypeByte{},
+													},
+													StructTag: "json:\"-\"",
+												},
+												{
+													Name:      "XXX_sizecache",
+													Type:      gopkg.TypeInt32{},
+													StructTag: "json:\"-\"",
+												},
+											},
+										},
 									},
 								},
 								gopkg.TypeError{},
@@ -863,6 +1321,32 @@ func TestParseDir(t *testing.T) {
 										ValueType: gopkg.TypeNamed{
 											Name:   "ShopspringDecimal",
 											Import: "some/import/proto_conversion",
+											ValueType: gopkg.TypeStruct{
+												Fields: []gopkg.DeclVar{
+													{
+														Name:      "Value",
+														Type:      gopkg.TypeString{},
+														StructTag: "protobuf:\"bytes,1,opt,name=value,proto3\" json:\"value,omitempty\"",
+													},
+													{
+														Name:      "XXX_NoUnkeyedLiteral",
+														Type:      gopkg.TypeStruct{},
+														StructTag: "json:\"-\"",
+													},
+													{
+														Name: "XXX_unrecognized",
+														Type: gopkg.TypeArray{
+															ValueType: gopkg.TypeByte{},
+														},
+														StructTag: "json:\"-\"",
+													},
+													{
+														Name:      "XXX_sizecache",
+														Type:      gopkg.TypeInt32{},
+														StructTag: "json:\"-\"",
+													},
+												},
+											},
 										},
 									},
 								},
@@ -893,6 +1377,32 @@ func TestParseDir(t *testing.T) {
 									ValueType: gopkg.TypeNamed{
 										Name:   "ShopspringDecimal",
 										Import: "some/import/proto_conversion",
+										ValueType: gopkg.TypeStruct{
+											Fields: []gopkg.DeclVar{
+												{
+													Name:      "Value",
+													Type:      gopkg.TypeString{},
+													StructTag: "protobuf:\"bytes,1,opt,name=value,proto3\" json:\"value,omitempty\"",
+												},
+												{
+													Name:      "XXX_NoUnkeyedLiteral",
+													Type:      gopkg.TypeStruct{},
+													StructTag: "json:\"-\"",
+												},
+												{
+													Name: "XXX_unrecognized",
+													Type: gopkg.TypeArray{
+														ValueType: gopkg.TypeByte{},
+													},
+													StructTag: "json:\"-\"",
+												},
+												{
+													Name:      "XXX_sizecache",
+													Type:      gopkg.TypeInt32{},
+													StructTag: "json:\"-\"",
+												},
+											},
+										},
 									},
 								},
 								gopkg.TypeError{},
@@ -910,6 +1420,7 @@ func TestParseDir(t *testing.T) {
 					Filepath:          "test_packages/proto_conversion/def.pb.go",
 					PackageName:       "proto_conversion",
 					PackageImportPath: "some/import/proto_conversion",
+					GeneratedBy:       "protoc-gen-go",
 					Imports: []gopkg.ImportAndAlias{
 						{
 							Import: "fmt",
@@ -926,9 +1437,14 @@ func TestParseDir(t *testing.T) {
 					},
 					Consts: []gopkg.DeclVar{
 						{
-							Name:   "_",
-							Import: "some/import/proto_conversion",
-							Type:   gopkg.TypeUnnamedLiteral{},
+							Name:         "_",
+							Import:       "some/import/proto_conversion",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "proto.ProtoPackageIsVersion3",
+							Comment:      "// please upgrade the proto package",
+							LiteralValueImports: map[string]bool{
+								"github.com/golang/protobuf/proto": true,
+							},
 							DocString: `// This is a compile-time assertion to ensure that this generated file
 // is compatible with the proto package it is being compiled against.
 // A compilation error at this line likely means your copy of the
@@ -937,24 +1453,39 @@ func TestParseDir(t *testing.T) {
 					},
 					Vars: []gopkg.DeclVar{
 						{
-							Name:      "_",
-							Import:    "some/import/proto_conversion",
-							Type:      gopkg.TypeUnnamedLiteral{},
+							Name:         "_",
+							Import:       "some/import/proto_conversion",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "proto.Marshal",
+							LiteralValueImports: map[string]bool{
+								"github.com/golang/protobuf/proto": true,
+							},
 							DocString: `// Reference imports to suppress errors if they are not otherwise used.`,
 						},
 						{
-							Name:   "_",
-							Import: "some/import/proto_conversion",
-							Type:   gopkg.TypeUnnamedLiteral{},
+							Name:         "_",
+							Import:       "some/import/proto_conversion",
+							Group:        1,
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "fmt.Errorf",
+							LiteralValueImports: map[string]bool{
+								"fmt": true,
+							},
 						},
 						{
-							Name:   "_",
-							Import: "some/import/proto_conversion",
-							Type:   gopkg.TypeUnnamedLiteral{},
+							Name:         "_",
+							Import:       "some/import/proto_conversion",
+							Group:        2,
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "math.Inf",
+							LiteralValueImports: map[string]bool{
+								"math": true,
+							},
 						},
 						{
 							Name:   "xxx_messageInfo_IntAsString",
 							Import: "some/import/proto_conversion",
+							Group:  3,
 							Type: gopkg.TypeNamed{
 								Name:   "InternalMessageInfo",
 								Import: "github.com/golang/protobuf/proto",
@@ -963,15 +1494,18 @@ func TestParseDir(t *testing.T) {
 						{
 							Name:   "xxx_messageInfo_ShopspringDecimal",
 							Import: "some/import/proto_conversion",
+							Group:  4,
 							Type: gopkg.TypeNamed{
 								Name:   "InternalMessageInfo",
 								Import: "github.com/golang/protobuf/proto",
 							},
 						},
 						{
-							Name:   "fileDescriptor_76fb0470a3b910d8",
-							Import: "some/import/proto_conversion",
-							Type:   gopkg.TypeUnnamedLiteral{},
+							Name:         "fileDescriptor_76fb0470a3b910d8",
+							Import:       "some/import/proto_conversion",
+							Group:        5,
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: protoConversionFileDescriptorLiteral,
 						},
 					},
 					Functions: protoConversionPackageFuncs(),
@@ -1042,6 +1576,9 @@ func TestParseDir(t *testing.T) {
 					Filepath:          "test_packages/proto_conversion/generate.go",
 					PackageName:       "proto_conversion",
 					PackageImportPath: "some/import/proto_conversion",
+					Directives: []string{
+						"//go:generate protoc --go_out=plugins=grpc:. def.proto",
+					},
 				},
 			},
 		},
@@ -1143,10 +1680,42 @@ func TestParseDir(t *testing.T) {
 						},
 					},
 				},
+				{
+					Filepath:          "test_packages/pkg_with_tests/logic_internal_test.go",
+					PackageName:       "pkg_with_tests",
+					PackageImportPath: "some/import/pkg_with_tests",
+					IsTest:            true,
+					Imports: tmpl.UnnamedImports(
+						"testing",
+					),
+					Functions: []gopkg.DeclFunc{
+						{
+							Name:   "TestMyCoolLogicInternal",
+							Import: "some/import/pkg_with_tests",
+							Args: []gopkg.DeclVar{
+								{
+									Name: "t",
+									Type: gopkg.TypePointer{
+										ValueType: gopkg.TypeNamed{
+											Name:   "T",
+											Import: "testing",
+										},
+									},
+								},
+							},
+							BodyTmpl: `
+	if MyCoolLogic(1, 1) != 2 {
+		t.Fail()
+	}
+`,
+						},
+					},
+				},
 				{
 					Filepath:          "test_packages/pkg_with_tests/logic_test.go",
 					PackageName:       "pkg_with_tests_test",
-					PackageImportPath: "some/import/pkg_with_tests",
+					PackageImportPath: "some/import/pkg_with_tests_test",
+					IsTest:            true,
 					Imports: tmpl.UnnamedImports(
 						"testing",
 						"github.com/stretchr/testify/require",
@@ -1155,7 +1724,7 @@ func TestParseDir(t *testing.T) {
 					Functions: []gopkg.DeclFunc{
 						{
 							Name:   "TestMyCoolLogic",
-							Import: "some/import/pkg_with_tests",
+							Import: "some/import/pkg_with_tests_test",
 							Args: []gopkg.DeclVar{
 								{
 									Name: "t",
@@ -1202,393 +1771,2059 @@ func TestParseDir(t *testing.T) {
 			},
 		},
 		{
-			Name:   "named_return_args",
-			PkgDir: "test_packages/named_return_args",
+			Name:   "build_constraints",
+			PkgDir: "test_packages/build_constraints",
 			ParseOptions: []gopkg.ParseOption{
-				gopkg.ParseWithPkgImportPath("myimport/named_return_args"),
+				gopkg.ParseWithPkgImportPath("some/import/build_constraints"),
 			},
 			Expected: []gopkg.FileContents{
 				{
-					Filepath:          "test_packages/named_return_args/named_return_args.go",
-					PackageName:       "named_return_args",
-					PackageImportPath: "myimport/named_return_args",
-					Types: []gopkg.DeclType{
+					Filepath:          "test_packages/build_constraints/shim.go",
+					PackageName:       "build_constraints",
+					PackageImportPath: "some/import/build_constraints",
+					Functions: []gopkg.DeclFunc{
 						{
-							Name:   "SomeInterface",
-							Import: "myimport/named_return_args",
-							Type: gopkg.TypeInterface{
-								Funcs: []gopkg.DeclFunc{
-									{
-										Name: "SomeFunc",
-										ReturnArgs: []gopkg.DeclVar{
-											{
-												Name: "a",
-												Type: gopkg.TypeInt{},
-											},
-											{
-												Name: "b",
-												Type: gopkg.TypeError{},
-											},
-										},
-									},
-									{
-										Name: "OtherFunc",
-										ReturnArgs: []gopkg.DeclVar{
-											{
-												Name: "c",
-												Type: gopkg.TypeInt64{},
-											},
-											{
-												Name: "d",
-												Type: gopkg.TypeInt64{},
-											},
-										},
-									},
-								},
-							},
+							Name:   "Name",
+							Import: "some/import/build_constraints",
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypeString{},
+							),
+							BodyTmpl: "\n\treturn platformName()\n",
 						},
 					},
+				},
+				{
+					Filepath:          "test_packages/build_constraints/shim_linux.go",
+					PackageName:       "build_constraints",
+					PackageImportPath: "some/import/build_constraints",
 					Functions: []gopkg.DeclFunc{
 						{
-							Name:   "MyMethod",
-							Import: "myimport/named_return_args",
-							ReturnArgs: []gopkg.DeclVar{
-								{
-									Name: "e",
-									Type: gopkg.TypeInt32{},
-								},
-								{
-									Name: "f",
-									Type: gopkg.TypeInt32{},
-								},
-								{
-									Name: "g",
-									Type: gopkg.TypeInt32{},
-								},
-							},
-							BodyTmpl: "\n\n\treturn 0, 0, 0\n",
+							Name:   "platformName",
+							Import: "some/import/build_constraints",
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypeString{},
+							),
+							BodyTmpl: "\n\treturn \"linux\"\n",
+						},
+					},
+				},
+				{
+					Filepath:          "test_packages/build_constraints/shim_other.go",
+					PackageName:       "build_constraints",
+					PackageImportPath: "some/import/build_constraints",
+					BuildConstraint:   "!linux",
+					Functions: []gopkg.DeclFunc{
+						{
+							Name:   "platformName",
+							Import: "some/import/build_constraints",
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypeString{},
+							),
+							BodyTmpl: "\n\treturn \"other\"\n",
 						},
+					},
+				},
+				{
+					Filepath:          "test_packages/build_constraints/tagged.go",
+					PackageName:       "build_constraints",
+					PackageImportPath: "some/import/build_constraints",
+					Consts: []gopkg.DeclVar{
 						{
-							Name:   "MyOtherMethod",
-							Import: "myimport/named_return_args",
-							ReturnArgs: []gopkg.DeclVar{
-								{
-									Name: "i",
-									Type: gopkg.TypeInt32{},
-								},
-								{
-									Name: "j",
-									Type: gopkg.TypeFloat64{},
-								},
-								{
-									Name: "k",
-									Type: gopkg.TypeError{},
-								},
-							},
-							BodyTmpl: "\n\n\treturn 0, 0, nil\n",
+							Name:         "Tagged",
+							Import:       "some/import/build_constraints",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "true",
 						},
 					},
+					DocString:       "// Package build_constraints has files for different platforms",
+					BuildConstraint: "mytag && (amd64 || arm64)",
 				},
 			},
 		},
 		{
-			Name:   "structs_with_tags",
-			PkgDir: "test_packages/struct_with_tags",
+			Name:   "build_constraints with linux build context",
+			PkgDir: "test_packages/build_constraints",
 			ParseOptions: []gopkg.ParseOption{
-				gopkg.ParseWithPkgImportPath("myimport/struct_with_tags"),
+				gopkg.ParseWithPkgImportPath("some/import/build_constraints"),
+				gopkg.ParseWithBuildContext(buildContext("linux", "amd64")),
 			},
 			Expected: []gopkg.FileContents{
 				{
-					Filepath:          "test_packages/struct_with_tags/acouple_of_structs.go",
-					PackageName:       "struct_with_tags",
-					PackageImportPath: "myimport/struct_with_tags",
-					Types: []gopkg.DeclType{
+					Filepath:          "test_packages/build_constraints/shim.go",
+					PackageName:       "build_constraints",
+					PackageImportPath: "some/import/build_constraints",
+					Functions: []gopkg.DeclFunc{
 						{
-							Name:   "AStruct",
-							Import: "myimport/struct_with_tags",
-							Type: gopkg.TypeStruct{
-								Fields: []gopkg.DeclVar{
-									{
-										Name:      "AField",
-										Type:      gopkg.TypeInt{},
-										StructTag: "AKey:\"some_value\"",
-									},
-									{
-										Name:      "BField",
-										Type:      gopkg.TypeBool{},
-										StructTag: "BKey:\"some_other_value\"",
-									},
-									{
-										Name:      "privateField",
-										Type:      gopkg.TypeFloat32{},
-										StructTag: "CKey:\"some_third_value\"",
-									},
-								},
-							},
+							Name:   "Name",
+							Import: "some/import/build_constraints",
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypeString{},
+							),
+							BodyTmpl: "\n\treturn platformName()\n",
+						},
+					},
+				},
+				{
+					Filepath:          "test_packages/build_constraints/shim_linux.go",
+					PackageName:       "build_constraints",
+					PackageImportPath: "some/import/build_constraints",
+					Functions: []gopkg.DeclFunc{
+						{
+							Name:   "platformName",
+							Import: "some/import/build_constraints",
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypeString{},
+							),
+							BodyTmpl: "\n\treturn \"linux\"\n",
 						},
 					},
 				},
 			},
 		},
 		{
-			Name:   "non_declaritive_elements",
-			PkgDir: "test_packages/non_declaritive_elements",
+			Name:   "build_constraints with darwin build context and tags",
+			PkgDir: "test_packages/build_constraints",
 			ParseOptions: []gopkg.ParseOption{
-				gopkg.ParseWithPkgImportPath("myimport/non_declaritive_elements"),
+				gopkg.ParseWithPkgImportPath("some/import/build_constraints"),
+				gopkg.ParseWithBuildContext(buildContext("darwin", "arm64", "mytag")),
 			},
 			Expected: []gopkg.FileContents{
 				{
-					Filepath:          "test_packages/non_declaritive_elements/docstrings.go",
-					PackageName:       "non_declaritive_elements",
-					PackageImportPath: "myimport/non_declaritive_elements",
-
-					DocString: "// A package level doc string\n// with\n//\n// multiple lines",
-					Consts: []gopkg.DeclVar{
+					Filepath:          "test_packages/build_constraints/shim.go",
+					PackageName:       "build_constraints",
+					PackageImportPath: "some/import/build_constraints",
+					Functions: []gopkg.DeclFunc{
 						{
-							Name:         "firstConstant",
-							Import:       "myimport/non_declaritive_elements",
-							Type:         gopkg.TypeInt32{},
-							LiteralValue: "1",
-							DocString:    "// a doc string on a group of consts",
+							Name:   "Name",
+							Import: "some/import/build_constraints",
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypeString{},
+							),
+							BodyTmpl: "\n\treturn platformName()\n",
 						},
+					},
+				},
+				{
+					Filepath:          "test_packages/build_constraints/shim_other.go",
+					PackageName:       "build_constraints",
+					PackageImportPath: "some/import/build_constraints",
+					BuildConstraint:   "!linux",
+					Functions: []gopkg.DeclFunc{
 						{
-							Name:         "secondConstant",
-							Import:       "myimport/non_declaritive_elements",
-							Type:         gopkg.TypeString{},
-							LiteralValue: `"hello"`,
-							DocString:    "// another with\n\t// several lines",
-						},
-						{
-							Name:         "thirdC",
-							Import:       "myimport/non_declaritive_elements",
-							Type:         gopkg.TypeUnnamedLiteral{},
-							LiteralValue: "10",
-							DocString:    "// some comment on multiple values",
-						},
-						{
-							Name:         "fourthC",
-							Import:       "myimport/non_declaritive_elements",
-							Type:         gopkg.TypeUnnamedLiteral{},
-							LiteralValue: "12",
-							DocString:    "// some comment on multiple values",
+							Name:   "platformName",
+							Import: "some/import/build_constraints",
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypeString{},
+							),
+							BodyTmpl: "\n\treturn \"other\"\n",
 						},
 					},
-					Vars: []gopkg.DeclVar{
-						{
-							Name:      "singleVar",
-							Import:    "myimport/non_declaritive_elements",
-							Type:      gopkg.TypeInt64{},
-							DocString: "// singleVar has a docstring\n// with multiple lines",
-						},
+				},
+				{
+					Filepath:          "test_packages/build_constraints/tagged.go",
+					PackageName:       "build_constraints",
+					PackageImportPath: "some/import/build_constraints",
+					Consts: []gopkg.DeclVar{
 						{
-							Name:      "someVar",
-							Import:    "myimport/non_declaritive_elements",
-							Type:      gopkg.TypeInt{},
-							DocString: "// only docstrings inside var groups are kept",
+							Name:         "Tagged",
+							Import:       "some/import/build_constraints",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "true",
 						},
 					},
+					DocString:       "// Package build_constraints has files for different platforms",
+					BuildConstraint: "mytag && (amd64 || arm64)",
+				},
+			},
+		},
+		{
+			Name:   "recursive_types with dependent types",
+			PkgDir: "test_packages/recursive_types",
+			ParseOptions: []gopkg.ParseOption{
+				gopkg.ParseWithPkgImportPath("some/import/recursive_types"),
+				gopkg.ParseDependentTypes(),
+			},
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "test_packages/recursive_types/types.go",
+					PackageName:       "recursive_types",
+					PackageImportPath: "some/import/recursive_types",
+					Imports: tmpl.UnnamedImports(
+						"github.com/thecodedproject/gopkg/test_packages/recursive_types/other",
+					),
 					Types: []gopkg.DeclType{
 						{
-							Name:      "adocumentedType",
-							Import:    "myimport/non_declaritive_elements",
-							Type:      gopkg.TypeString{},
-							DocString: "// adocumentedType with a docstring\n// and multiple lines\n// of text",
-						},
-					},
-					Functions: []gopkg.DeclFunc{
-						{
-							Name:      "ExportedMethod",
-							Import:    "myimport/non_declaritive_elements",
-							DocString: "// a docstring not starting with the method name",
+							Name:   "Node",
+							Import: "some/import/recursive_types",
+							Type: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{
+										Name: "Value",
+										Type: gopkg.TypeNamed{
+											Name:   "Value",
+											Import: "some/import/recursive_types",
+											ValueType: gopkg.TypeStruct{
+												Fields: []gopkg.DeclVar{
+													{
+														Name: "Other",
+														Type: gopkg.TypeNamed{
+															Name:   "Wrapper",
+															Import: "github.com/thecodedproject/gopkg/test_packages/recursive_types/other",
+														},
+													},
+												},
+											},
+										},
+									},
+									{
+										Name: "Next",
+										Type: gopkg.TypePointer{
+											ValueType: gopkg.TypeNamed{
+												Name:   "Node",
+												Import: "some/import/recursive_types",
+											},
+										},
+									},
+								},
+							},
 						},
 						{
-							Name:      "unexportedMethod",
-							Import:    "myimport/non_declaritive_elements",
-							DocString: "// unexported method docstring",
+							Name:   "Value",
+							Import: "some/import/recursive_types",
+							Type: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{
+										Name: "Other",
+										Type: gopkg.TypeNamed{
+											Name:   "Wrapper",
+											Import: "github.com/thecodedproject/gopkg/test_packages/recursive_types/other",
+											ValueType: gopkg.TypeStruct{
+												Fields: []gopkg.DeclVar{
+													{
+														Name: "Inner",
+														Type: gopkg.TypeNamed{
+															Name:   "Inner",
+															Import: "github.com/thecodedproject/gopkg/test_packages/recursive_types/other",
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
 						},
 					},
 				},
+			},
+		},
+		{
+			Name:   "recursive_types with unlimited dependent type depth",
+			PkgDir: "test_packages/recursive_types",
+			ParseOptions: []gopkg.ParseOption{
+				gopkg.ParseWithPkgImportPath("some/import/recursive_types"),
+				gopkg.ParseDependentTypesDepth(-1),
+			},
+			Expected: []gopkg.FileContents{
 				{
-					Filepath:          "test_packages/non_declaritive_elements/function_contents.go",
-					PackageName:       "non_declaritive_elements",
-					PackageImportPath: "myimport/non_declaritive_elements",
+					Filepath:          "test_packages/recursive_types/types.go",
+					PackageName:       "recursive_types",
+					PackageImportPath: "some/import/recursive_types",
+					Imports: tmpl.UnnamedImports(
+						"github.com/thecodedproject/gopkg/test_packages/recursive_types/other",
+					),
 					Types: []gopkg.DeclType{
 						{
-							Name:   "someType",
-							Import: "myimport/non_declaritive_elements",
-							Type:   gopkg.TypeStruct{},
-						},
-					},
-					Functions: []gopkg.DeclFunc{
-						{
-							Name:       "SomeFunc",
-							Import:     "myimport/non_declaritive_elements",
-							ReturnArgs: tmpl.UnnamedReturnArgs(gopkg.TypeInt64{}),
-							BodyTmpl: `
-	// A comment...
-	var a int64
-	a = 1234
-	b := a
-	return b
-`,
-						},
-						{
-							Name:   "receiverMethod",
-							Import: "myimport/non_declaritive_elements",
-							Receiver: gopkg.FuncReceiver{
-								VarName:  "s",
-								TypeName: "someType",
+							Name:   "Node",
+							Import: "some/import/recursive_types",
+							Type: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{
+										Name: "Value",
+										Type: gopkg.TypeNamed{
+											Name:   "Value",
+											Import: "some/import/recursive_types",
+											ValueType: gopkg.TypeStruct{
+												Fields: []gopkg.DeclVar{
+													{
+														Name: "Other",
+														Type: gopkg.TypeNamed{
+															Name:   "Wrapper",
+															Import: "github.com/thecodedproject/gopkg/test_packages/recursive_types/other",
+															ValueType: gopkg.TypeStruct{
+																Fields: []gopkg.DeclVar{
+																	{
+																		Name: "Inner",
+																		Type: gopkg.TypeNamed{
+																			Name:      "Inner",
+																			Import:    "github.com/thecodedproject/gopkg/test_packages/recursive_types/other",
+																			ValueType: gopkg.TypeInt{},
+																		},
+																	},
+																},
+															},
+														},
+													},
+												},
+											},
+										},
+									},
+									{
+										Name: "Next",
+										Type: gopkg.TypePointer{
+											ValueType: gopkg.TypeNamed{
+												Name:   "Node",
+												Import: "some/import/recursive_types",
+											},
+										},
+									},
+								},
 							},
-							ReturnArgs: tmpl.UnnamedReturnArgs(gopkg.TypeBool{}),
-							BodyTmpl: `
-	//some reciever method comment...
-	return true
-`,
 						},
 						{
-							Name:       "unexportedFunc",
-							Import:     "myimport/non_declaritive_elements",
-							ReturnArgs: tmpl.UnnamedReturnArgs(gopkg.TypeString{}),
-							BodyTmpl: `
-	// some other comment...
-	return "foobar"
-`,
+							Name:   "Value",
+							Import: "some/import/recursive_types",
+							Type: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{
+										Name: "Other",
+										Type: gopkg.TypeNamed{
+											Name:   "Wrapper",
+											Import: "github.com/thecodedproject/gopkg/test_packages/recursive_types/other",
+											ValueType: gopkg.TypeStruct{
+												Fields: []gopkg.DeclVar{
+													{
+														Name: "Inner",
+														Type: gopkg.TypeNamed{
+															Name:      "Inner",
+															Import:    "github.com/thecodedproject/gopkg/test_packages/recursive_types/other",
+															ValueType: gopkg.TypeInt{},
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
 						},
 					},
 				},
+			},
+		},
+		{
+			Name:   "pkg_with_tests excluding tests",
+			PkgDir: "test_packages/pkg_with_tests",
+			ParseOptions: []gopkg.ParseOption{
+				gopkg.ParseWithPkgImportPath("some/import/pkg_with_tests"),
+				gopkg.ParseExcludeTests(),
+			},
+			Expected: []gopkg.FileContents{
 				{
-					Filepath:          "test_packages/non_declaritive_elements/imports.go",
-					PackageName:       "non_declaritive_elements",
-					PackageImportPath: "myimport/non_declaritive_elements",
-					Imports: []gopkg.ImportAndAlias{
-						{
-							Import: "context",
-						},
-						{
-							Import: "github.com/thecodedproject/gopkg",
-							Alias: "aliased_import",
-						},
-					},
-					Vars: []gopkg.DeclVar{
-						{
-							Name:   "c",
-							Import: "myimport/non_declaritive_elements",
-							Type:   gopkg.TypeNamed{
-								Name: "Context",
-								Import: "context",
-							},
-						},
+					Filepath:          "test_packages/pkg_with_tests/logic.go",
+					PackageName:       "pkg_with_tests",
+					PackageImportPath: "some/import/pkg_with_tests",
+					Functions: []gopkg.DeclFunc{
 						{
-							Name:   "t",
-							Import: "myimport/non_declaritive_elements",
-							Type:   gopkg.TypeNamed{
-								Name: "TypeInt",
-								Import: "github.com/thecodedproject/gopkg",
+							Name:   "MyCoolLogic",
+							Import: "some/import/pkg_with_tests",
+							Args: []gopkg.DeclVar{
+								{
+									Name: "i",
+									Type: gopkg.TypeInt{},
+								},
+								{
+									Name: "j",
+									Type: gopkg.TypeInt{},
+								},
 							},
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypeInt{},
+							),
+							BodyTmpl: "\n\treturn i + j\n",
 						},
 					},
 				},
 			},
 		},
 		{
-			Name:   "dependent_types",
-			PkgDir: "test_packages/dependent_types",
+			Name:   "named_return_args",
+			PkgDir: "test_packages/named_return_args",
 			ParseOptions: []gopkg.ParseOption{
-				gopkg.ParseWithPkgImportPath("myimport/some_dependent_types"),
-				gopkg.ParseDependentTypes(),
+				gopkg.ParseWithPkgImportPath("myimport/named_return_args"),
+			},
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "test_packages/named_return_args/named_return_args.go",
+					PackageName:       "named_return_args",
+					PackageImportPath: "myimport/named_return_args",
+					Types: []gopkg.DeclType{
+						{
+							Name:   "SomeInterface",
+							Import: "myimport/named_return_args",
+							Type: gopkg.TypeInterface{
+								Funcs: []gopkg.DeclFunc{
+									{
+										Name: "SomeFunc",
+										ReturnArgs: []gopkg.DeclVar{
+											{
+												Name: "a",
+												Type: gopkg.TypeInt{},
+											},
+											{
+												Name: "b",
+												Type: gopkg.TypeError{},
+											},
+										},
+									},
+									{
+										Name: "OtherFunc",
+										ReturnArgs: []gopkg.DeclVar{
+											{
+												Name: "c",
+												Type: gopkg.TypeInt64{},
+											},
+											{
+												Name: "d",
+												Type: gopkg.TypeInt64{},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Functions: []gopkg.DeclFunc{
+						{
+							Name:   "MyMethod",
+							Import: "myimport/named_return_args",
+							ReturnArgs: []gopkg.DeclVar{
+								{
+									Name: "e",
+									Type: gopkg.TypeInt32{},
+								},
+								{
+									Name: "f",
+									Type: gopkg.TypeInt32{},
+								},
+								{
+									Name: "g",
+									Type: gopkg.TypeInt32{},
+								},
+							},
+							BodyTmpl: "\n\n\treturn 0, 0, 0\n",
+						},
+						{
+							Name:   "MyOtherMethod",
+							Import: "myimport/named_return_args",
+							ReturnArgs: []gopkg.DeclVar{
+								{
+									Name: "i",
+									Type: gopkg.TypeInt32{},
+								},
+								{
+									Name: "j",
+									Type: gopkg.TypeFloat64{},
+								},
+								{
+									Name: "k",
+									Type: gopkg.TypeError{},
+								},
+							},
+							BodyTmpl: "\n\n\treturn 0, 0, nil\n",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:   "generics",
+			PkgDir: "test_packages/generics",
+			ParseOptions: []gopkg.ParseOption{
+				gopkg.ParseWithPkgImportPath("myimport/generics"),
+			},
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "test_packages/generics/generics.go",
+					PackageName:       "generics",
+					PackageImportPath: "myimport/generics",
+					Imports:           tmpl.UnnamedImports("context"),
+					Types: []gopkg.DeclType{
+						{
+							Name:   "Number",
+							Import: "myimport/generics",
+							Type: gopkg.TypeInterface{
+								Embeds: []gopkg.Type{
+									gopkg.TypeUnion{
+										Terms: []gopkg.TypeUnionTerm{
+											{Type: gopkg.TypeInt{}, Tilde: true},
+											{Type: gopkg.TypeInt64{}, Tilde: true},
+											{Type: gopkg.TypeFloat64{}},
+										},
+									},
+								},
+								Funcs: []gopkg.DeclFunc{},
+							},
+						},
+						{
+							Name:   "Set",
+							Import: "myimport/generics",
+							TypeParams: []gopkg.TypeParam{
+								{
+									Name: "K",
+									Constraint: gopkg.TypeComparable{},
+								},
+								{
+									Name: "V",
+									Constraint: gopkg.TypeNamed{
+										Name:   "Number",
+										Import: "myimport/generics",
+									},
+								},
+							},
+							Type: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{
+										Name: "Items",
+										Type: gopkg.TypeMap{
+											KeyType:   gopkg.TypeNamed{Name: "K"},
+											ValueType: gopkg.TypeNamed{Name: "V"},
+										},
+									},
+								},
+							},
+						},
+						{
+							Name:   "List",
+							Import: "myimport/generics",
+							TypeParams: []gopkg.TypeParam{
+								{
+									Name: "T",
+									Constraint: gopkg.TypeInterface{
+										Funcs: []gopkg.DeclFunc{},
+									},
+								},
+							},
+							Type: gopkg.TypeArray{
+								ValueType: gopkg.TypeNamed{Name: "T"},
+							},
+						},
+					},
+					Functions: []gopkg.DeclFunc{
+						{
+							Name:   "Add",
+							Import: "myimport/generics",
+							Receiver: gopkg.FuncReceiver{
+								VarName:    "s",
+								TypeName:   "Set",
+								IsPointer:  true,
+								TypeParams: []string{"K", "V"},
+							},
+							Args: []gopkg.DeclVar{
+								{
+									Name: "k",
+									Type: gopkg.TypeNamed{Name: "K"},
+								},
+								{
+									Name: "v",
+									Type: gopkg.TypeNamed{Name: "V"},
+								},
+							},
+							BodyTmpl: "\n\ts.Items[k] = v\n",
+						},
+						{
+							Name:   "Sum",
+							Import: "myimport/generics",
+							Receiver: gopkg.FuncReceiver{
+								VarName:    "s",
+								TypeName:   "Set",
+								TypeParams: []string{"_", "V"},
+							},
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypeNamed{Name: "V"},
+							),
+							BodyTmpl: "\n\tvar sum V\n\tfor _, v := range s.Items {\n\t\tsum += v\n\t}\n\treturn sum\n",
+						},
+						{
+							Name:   "MapKeys",
+							Import: "myimport/generics",
+							TypeParams: []gopkg.TypeParam{
+								{
+									Name: "S",
+									Constraint: gopkg.TypeUnion{
+										Terms: []gopkg.TypeUnionTerm{
+											{
+												Type: gopkg.TypeArray{
+													ValueType: gopkg.TypeNamed{Name: "E"},
+												},
+												Tilde: true,
+											},
+										},
+									},
+								},
+								{
+									Name: "E",
+									Constraint: gopkg.TypeComparable{},
+								},
+							},
+							Args: []gopkg.DeclVar{
+								{
+									Name: "ctx",
+									Type: gopkg.TypeNamed{
+										Name:   "Context",
+										Import: "context",
+									},
+								},
+								{
+									Name: "s",
+									Type: gopkg.TypeNamed{Name: "S"},
+								},
+							},
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypePointer{
+									ValueType: gopkg.TypeGeneric{
+										Name:   "Set",
+										Import: "myimport/generics",
+										TypeArgs: []gopkg.Type{
+											gopkg.TypeNamed{Name: "E"},
+											gopkg.TypeInt{},
+										},
+										ValueType: gopkg.TypeStruct{
+											Fields: []gopkg.DeclVar{
+												{
+													Name: "Items",
+													Type: gopkg.TypeMap{
+														KeyType:   gopkg.TypeNamed{Name: "K"},
+														ValueType: gopkg.TypeNamed{Name: "V"},
+													},
+												},
+											},
+										},
+									},
+								},
+							),
+							BodyTmpl: "\n\treturn nil\n",
+						},
+						{
+							Name:   "ListOfContexts",
+							Import: "myimport/generics",
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypeGeneric{
+									Name:   "List",
+									Import: "myimport/generics",
+									TypeArgs: []gopkg.Type{
+										gopkg.TypeNamed{
+											Name:   "Context",
+											Import: "context",
+										},
+									},
+									ValueType: gopkg.TypeArray{
+										ValueType: gopkg.TypeNamed{Name: "T"},
+									},
+								},
+							),
+							BodyTmpl: "\n\treturn nil\n",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:   "multiple_packages",
+			PkgDir: "test_packages/multiple_packages",
+			ParseOptions: []gopkg.ParseOption{
+				gopkg.ParseWithPkgImportPath("myimport/multiple_packages"),
+			},
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "test_packages/multiple_packages/shapes.go",
+					PackageName:       "multiple_packages",
+					PackageImportPath: "myimport/multiple_packages",
+					Vars: []gopkg.DeclVar{
+						{
+							Name:   "Default",
+							Import: "myimport/multiple_packages",
+							Type: gopkg.TypeNamed{
+								Name:   "Shape",
+								Import: "myimport/multiple_packages",
+								ValueType: gopkg.TypeStruct{
+									Fields: []gopkg.DeclVar{
+										{Name: "Name", Type: gopkg.TypeString{}},
+									},
+								},
+							},
+						},
+					},
+					Types: []gopkg.DeclType{
+						{
+							Name:   "Shape",
+							Import: "myimport/multiple_packages",
+							Type: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{Name: "Name", Type: gopkg.TypeString{}},
+								},
+							},
+						},
+					},
+				},
+				{
+					Filepath:          "test_packages/multiple_packages/gen.go",
+					PackageName:       "main",
+					PackageImportPath: "myimport/multiple_packages#main",
+					BuildConstraint:   "ignore",
+					Vars: []gopkg.DeclVar{
+						{
+							Name:   "Default",
+							Import: "myimport/multiple_packages#main",
+							Type: gopkg.TypeNamed{
+								Name:      "Shape",
+								Import:    "myimport/multiple_packages#main",
+								ValueType: gopkg.TypeInt{},
+							},
+						},
+					},
+					Types: []gopkg.DeclType{
+						{
+							Name:   "Shape",
+							Import: "myimport/multiple_packages#main",
+							Type:   gopkg.TypeInt{},
+						},
+					},
+					Functions: []gopkg.DeclFunc{
+						{
+							Name:   "main",
+							Import: "myimport/multiple_packages#main",
+						},
+					},
+				},
+				{
+					Filepath:          "test_packages/multiple_packages/shapes_test.go",
+					PackageName:       "multiple_packages_test",
+					PackageImportPath: "myimport/multiple_packages_test",
+					IsTest:            true,
+					Vars: []gopkg.DeclVar{
+						{
+							Name:   "Tested",
+							Import: "myimport/multiple_packages_test",
+							Type:   gopkg.TypeBool{},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:   "const_groups",
+			PkgDir: "test_packages/const_groups",
+			ParseOptions: []gopkg.ParseOption{
+				gopkg.ParseWithPkgImportPath("myimport/const_groups"),
+			},
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "test_packages/const_groups/enums.go",
+					PackageName:       "const_groups",
+					PackageImportPath: "myimport/const_groups",
+					Consts: []gopkg.DeclVar{
+						{
+							Name:         "ColourUnknown",
+							Import:       "myimport/const_groups",
+							Type:         gopkg.TypeNamed{Name: "Colour", Import: "myimport/const_groups", ValueType: gopkg.TypeInt{}},
+							LiteralValue: "iota",
+						},
+						{
+							Name:         "ColourRed",
+							Import:       "myimport/const_groups",
+							Type:         gopkg.TypeNamed{Name: "Colour", Import: "myimport/const_groups", ValueType: gopkg.TypeInt{}},
+							LiteralValue: "iota",
+							Iota:         1,
+							IsImplicit:   true,
+						},
+						{
+							Name:         "ColourGreen",
+							Import:       "myimport/const_groups",
+							Type:         gopkg.TypeNamed{Name: "Colour", Import: "myimport/const_groups", ValueType: gopkg.TypeInt{}},
+							LiteralValue: "iota",
+							Iota:         2,
+							IsImplicit:   true,
+						},
+						{
+							Name:         "_",
+							Import:       "myimport/const_groups",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "iota",
+							Group:        1,
+						},
+						{
+							Name:         "KB",
+							Import:       "myimport/const_groups",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "1 << (10 * iota)",
+							Group:        1,
+							Iota:         1,
+						},
+						{
+							Name:         "MB",
+							Import:       "myimport/const_groups",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "1 << (10 * iota)",
+							Group:        1,
+							Iota:         2,
+							IsImplicit:   true,
+						},
+						{
+							Name:         "A",
+							Import:       "myimport/const_groups",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "iota",
+							Group:        2,
+						},
+						{
+							Name:         "B",
+							Import:       "myimport/const_groups",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "iota * 10",
+							SharesSpec:   true,
+							Group:        2,
+						},
+						{
+							Name:         "C",
+							Import:       "myimport/const_groups",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "iota",
+							Group:        2,
+							Iota:         1,
+							IsImplicit:   true,
+						},
+						{
+							Name:         "D",
+							Import:       "myimport/const_groups",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "iota * 10",
+							SharesSpec:   true,
+							Group:        2,
+							Iota:         1,
+							IsImplicit:   true,
+						},
+						{
+							Name:         "Single",
+							Import:       "myimport/const_groups",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "iota",
+							Group:        3,
+						},
+					},
+					Types: []gopkg.DeclType{
+						{
+							Name:   "Colour",
+							Import: "myimport/const_groups",
+							Type:   gopkg.TypeInt{},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:   "dot_and_blank_imports",
+			PkgDir: "test_packages/dot_and_blank_imports",
+			ParseOptions: []gopkg.ParseOption{
+				gopkg.ParseWithPkgImportPath("myimport/dot_and_blank_imports"),
+			},
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "test_packages/dot_and_blank_imports/imports.go",
+					PackageName:       "dot_and_blank_imports",
+					PackageImportPath: "myimport/dot_and_blank_imports",
+					Imports: []gopkg.ImportAndAlias{
+						{
+							Import: "embed",
+							Alias:  "_",
+						},
+						{
+							Import: "github.com/thecodedproject/gopkg/test_packages/dependent_types/nested_pkg",
+							Alias:  ".",
+						},
+					},
+					Types: []gopkg.DeclType{
+						{
+							Name:   "LocalType",
+							Import: "myimport/dot_and_blank_imports",
+							Type:   gopkg.TypeStruct{},
+						},
+						{
+							Name:   "SomeStruct",
+							Import: "myimport/dot_and_blank_imports",
+							Type: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{
+										Name: "A",
+										Type: gopkg.TypeNamed{
+											Name:   "SomeType",
+											Import: "github.com/thecodedproject/gopkg/test_packages/dependent_types/nested_pkg",
+										},
+									},
+									{
+										Name: "B",
+										Type: gopkg.TypePointer{
+											ValueType: gopkg.TypeNamed{
+												Name:   "AnotherType",
+												Import: "github.com/thecodedproject/gopkg/test_packages/dependent_types/nested_pkg",
+											},
+										},
+									},
+									{
+										Name: "C",
+										Type: gopkg.TypeNamed{
+											Name:      "LocalType",
+											Import:    "myimport/dot_and_blank_imports",
+											ValueType: gopkg.TypeStruct{},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:   "structs_with_tags",
+			PkgDir: "test_packages/struct_with_tags",
+			ParseOptions: []gopkg.ParseOption{
+				gopkg.ParseWithPkgImportPath("myimport/struct_with_tags"),
+			},
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "test_packages/struct_with_tags/acouple_of_structs.go",
+					PackageName:       "struct_with_tags",
+					PackageImportPath: "myimport/struct_with_tags",
+					Types: []gopkg.DeclType{
+						{
+							Name:   "AStruct",
+							Import: "myimport/struct_with_tags",
+							Type: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{
+										Name:      "AField",
+										Type:      gopkg.TypeInt{},
+										StructTag: "AKey:\"some_value\"",
+									},
+									{
+										Name:      "BField",
+										Type:      gopkg.TypeBool{},
+										StructTag: "BKey:\"some_other_value\"",
+									},
+									{
+										Name:      "privateField",
+										Type:      gopkg.TypeFloat32{},
+										StructTag: "CKey:\"some_third_value\"",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:   "non_declaritive_elements",
+			PkgDir: "test_packages/non_declaritive_elements",
+			ParseOptions: []gopkg.ParseOption{
+				gopkg.ParseWithPkgImportPath("myimport/non_declaritive_elements"),
+			},
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "test_packages/non_declaritive_elements/docstrings.go",
+					PackageName:       "non_declaritive_elements",
+					PackageImportPath: "myimport/non_declaritive_elements",
+
+					DocString: "// A package level doc string\n// with\n//\n// multiple lines",
+					Consts: []gopkg.DeclVar{
+						{
+							Name:         "firstConstant",
+							Import:       "myimport/non_declaritive_elements",
+							Type:         gopkg.TypeInt32{},
+							LiteralValue: "1",
+							DocString:    "// a doc string on a group of consts",
+						},
+						{
+							Name:         "secondConstant",
+							Import:       "myimport/non_declaritive_elements",
+							Iota:         1,
+							Type:         gopkg.TypeString{},
+							LiteralValue: `"hello"`,
+							DocString:    "// another with\n\t// several lines",
+						},
+						{
+							Name:         "thirdC",
+							Import:       "myimport/non_declaritive_elements",
+							Iota:         2,
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "10",
+							DocString:    "// some comment on multiple values",
+						},
+						{
+							Name:         "fourthC",
+							Import:       "myimport/non_declaritive_elements",
+							Iota:         2,
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "12",
+							SharesSpec:   true,
+							DocString:    "// some comment on multiple values",
+						},
+					},
+					Vars: []gopkg.DeclVar{
+						{
+							Name:      "singleVar",
+							Import:    "myimport/non_declaritive_elements",
+							Type:      gopkg.TypeInt64{},
+							DocString: "// singleVar has a docstring\n// with multiple lines",
+						},
+						{
+							Name:           "someVar",
+							Import:         "myimport/non_declaritive_elements",
+							Group:          1,
+							Type:           gopkg.TypeInt{},
+							DocString:      "// only docstrings inside var groups are kept",
+							GroupDocString: "// comment on var grounds are ignored",
+						},
+					},
+					Types: []gopkg.DeclType{
+						{
+							Name:      "adocumentedType",
+							Import:    "myimport/non_declaritive_elements",
+							Type:      gopkg.TypeString{},
+							DocString: "// adocumentedType with a docstring\n// and multiple lines\n// of text",
+						},
+					},
+					Functions: []gopkg.DeclFunc{
+						{
+							Name:      "ExportedMethod",
+							Import:    "myimport/non_declaritive_elements",
+							DocString: "// a docstring not starting with the method name",
+						},
+						{
+							Name:      "unexportedMethod",
+							Import:    "myimport/non_declaritive_elements",
+							DocString: "// unexported method docstring",
+						},
+					},
+				},
+				{
+					Filepath:          "test_packages/non_declaritive_elements/function_contents.go",
+					PackageName:       "non_declaritive_elements",
+					PackageImportPath: "myimport/non_declaritive_elements",
+					Types: []gopkg.DeclType{
+						{
+							Name:   "someType",
+							Import: "myimport/non_declaritive_elements",
+							Type:   gopkg.TypeStruct{},
+						},
+					},
+					Functions: []gopkg.DeclFunc{
+						{
+							Name:       "SomeFunc",
+							Import:     "myimport/non_declaritive_elements",
+							ReturnArgs: tmpl.UnnamedReturnArgs(gopkg.TypeInt64{}),
+							BodyTmpl: `
+	// A comment...
+	var a int64
+	a = 1234
+	b := a
+	return b
+`,
+						},
+						{
+							Name:   "receiverMethod",
+							Import: "myimport/non_declaritive_elements",
+							Receiver: gopkg.FuncReceiver{
+								VarName:  "s",
+								TypeName: "someType",
+							},
+							ReturnArgs: tmpl.UnnamedReturnArgs(gopkg.TypeBool{}),
+							BodyTmpl: `
+	//some reciever method comment...
+	return true
+`,
+						},
+						{
+							Name:       "unexportedFunc",
+							Import:     "myimport/non_declaritive_elements",
+							ReturnArgs: tmpl.UnnamedReturnArgs(gopkg.TypeString{}),
+							BodyTmpl: `
+	// some other comment...
+	return "foobar"
+`,
+						},
+					},
+				},
+				{
+					Filepath:          "test_packages/non_declaritive_elements/imports.go",
+					PackageName:       "non_declaritive_elements",
+					PackageImportPath: "myimport/non_declaritive_elements",
+					Imports: []gopkg.ImportAndAlias{
+						{
+							Import: "context",
+						},
+						{
+							Import: "github.com/thecodedproject/gopkg",
+							Alias: "aliased_import",
+						},
+					},
+					Vars: []gopkg.DeclVar{
+						{
+							Name:   "c",
+							Import: "myimport/non_declaritive_elements",
+							Type:   gopkg.TypeNamed{
+								Name: "Context",
+								Import: "context",
+							},
+						},
+						{
+							Name:   "t",
+							Import: "myimport/non_declaritive_elements",
+							Type:   gopkg.TypeNamed{
+								Name: "TypeInt",
+								Import: "github.com/thecodedproject/gopkg",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:   "dependent_types",
+			PkgDir: "test_packages/dependent_types",
+			ParseOptions: []gopkg.ParseOption{
+				gopkg.ParseWithPkgImportPath("myimport/some_dependent_types"),
+				gopkg.ParseDependentTypes(),
+			},
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "test_packages/dependent_types/dependent_types.go",
+					PackageName:       "dependent_types",
+					PackageImportPath: "myimport/some_dependent_types",
+					Imports: tmpl.UnnamedImports(
+						"math/big",
+						"github.com/shopspring/decimal",
+						"github.com/thecodedproject/gopkg/test_packages/dependent_types/nested_pkg",
+					),
+					Types: []gopkg.DeclType{
+						{
+							Name: "AStruct",
+							Import: "myimport/some_dependent_types",
+							Type: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{
+										Name: "One",
+										Type: gopkg.TypeNamed{
+											Name: "Int",
+											Import: "math/big",
+											ValueType: gopkg.TypeStruct{
+												Fields: []gopkg.DeclVar{
+													{
+														Name:    "neg",
+														Type:    gopkg.TypeBool{},
+														Comment: "// sign",
+													},
+													{
+														Name: "abs",
+														Type: gopkg.TypeNamed{
+															Name: "nat",
+															Import: "math/big",
+														},
+														Comment: "// absolute value of the integer",
+													},
+												},
+											},
+										},
+									},
+									{
+										Name: "Two",
+										Type: gopkg.TypeNamed{
+											Name: "Decimal",
+											Import: "github.com/shopspring/decimal",
+											ValueType: gopkg.TypeStruct{
+												Fields: []gopkg.DeclVar{
+													{
+														Name: "value",
+														Type: gopkg.TypePointer{
+															ValueType: gopkg.TypeNamed{
+																Name: "Int",
+																Import: "math/big",
+															},
+														},
+													},
+													{
+														Name: "exp",
+														Type: gopkg.TypeInt32{},
+														DocString: `// NOTE(vadim): this must be an int32, because we cast it to float64 during
+// calculations. If exp is 64 bit, we might lose precision.
+// If we cared about being able to represent every possible decimal, we
+// could make exp a *big.Int but it would hurt performance and numbers
+// like that are unrealistic.`,
+													},
+												},
+											},
+										},
+									},
+									{
+										Name: "Three",
+										Type: gopkg.TypeNamed{
+											Name: "SomeType",
+											Import: "github.com/thecodedproject/gopkg/test_packages/dependent_types/nested_pkg",
+											ValueType: gopkg.TypePointer{
+												ValueType: gopkg.TypeInt32{},
+											},
+										},
+									},
+									{
+										Name: "Four",
+										Type: gopkg.TypeNamed{
+											Name: "AnotherType",
+											Import: "github.com/thecodedproject/gopkg/test_packages/dependent_types/nested_pkg",
+											ValueType: gopkg.TypeStruct{
+												Fields: []gopkg.DeclVar{
+													{
+														Name: "A",
+														Type: gopkg.TypeString{},
+													},
+													{
+														Name: "B",
+														Type: gopkg.TypeNamed{
+															Name: "Context",
+															Import: "context",
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			pc, err := gopkg.Parse(test.PkgDir, test.ParseOptions...)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, pc)
+		})
+	}
+}
+
+// buildContext returns the default build context for `goos` and `goarch`
+// with the given build tags
+func buildContext(goos string, goarch string, tags ...string) build.Context {
+
+	ctxt := build.Default
+	ctxt.GOOS = goos
+	ctxt.GOARCH = goarch
+	ctxt.BuildTags = tags
+	return ctxt
+}
+
+func TestParseSingleFile(t *testing.T) {
+
+	testCases := []struct{
+		Name string
+		InputFile string
+		ParseOptions []gopkg.ParseOption
+		Expected []gopkg.FileContents
+	}{
+		{
+			Name: "very_simple/very_simple.go",
+			InputFile: "test_packages/very_simple/very_simple.go",
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "test_packages/very_simple/very_simple.go",
+					PackageName:       "very_simple",
+					PackageImportPath: "github.com/thecodedproject/gopkg/test_packages/very_simple",
+					Vars: []gopkg.DeclVar{
+						{
+							Name:   "MyVar",
+							Import: "github.com/thecodedproject/gopkg/test_packages/very_simple",
+							Type:   gopkg.TypeInt{},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			pc, err := gopkg.Parse(test.InputFile, test.ParseOptions...)
+			require.NoError(t, err)
+			assert.Equal(t, test.Expected, pc)
+		})
+	}
+}
+
+// TestParseAndWriteSingleFile checks that a roundtrip (parse + generate) of a single produces the desired result
+func TestParseSources(t *testing.T) {
+
+	testCases := []struct {
+		Name          string
+		Sources       map[string][]byte
+		PkgImportPath string
+		ParseOptions  []gopkg.ParseOption
+		Expected      []gopkg.FileContents
+		ExpectedErr   string
+	}{
+		{
+			Name: "empty import path returns error",
+			Sources: map[string][]byte{
+				"a.go": []byte("package a\n"),
+			},
+			ExpectedErr: "package import path cannot be empty",
+		},
+		{
+			Name: "invalid source returns error",
+			Sources: map[string][]byte{
+				"a.go": []byte("package a\n\nvar A = \n"),
+			},
+			PkgImportPath: "myimport/a",
+			ExpectedErr:   "a.go:3:10: expected operand, found 'EOF'",
+		},
+		{
+			Name: "multiple files are sorted by filename",
+			Sources: map[string][]byte{
+				"generated/b.go": []byte(`package a
+
+// B has a docstring
+func B() int {
+	return A
+}
+`),
+				"generated/a.go": []byte(`package a
+
+import "time"
+
+const A = 1
+
+var Timeout = time.Second
+`),
+			},
+			PkgImportPath: "myimport/a",
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "generated/a.go",
+					PackageName:       "a",
+					PackageImportPath: "myimport/a",
+					Imports: []gopkg.ImportAndAlias{
+						{Import: "time"},
+					},
+					Consts: []gopkg.DeclVar{
+						{
+							Name:         "A",
+							Import:       "myimport/a",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "1",
+						},
+					},
+					Vars: []gopkg.DeclVar{
+						{
+							Name:         "Timeout",
+							Import:       "myimport/a",
+							Type:         gopkg.TypeUnnamedLiteral{},
+							LiteralValue: "time.Second",
+							LiteralValueImports: map[string]bool{
+								"time": true,
+							},
+						},
+					},
+				},
+				{
+					Filepath:          "generated/b.go",
+					PackageName:       "a",
+					PackageImportPath: "myimport/a",
+					Functions: []gopkg.DeclFunc{
+						{
+							Name:       "B",
+							Import:     "myimport/a",
+							ReturnArgs: tmpl.UnnamedReturnArgs(gopkg.TypeInt{}),
+							BodyTmpl:   "\n\treturn A\n",
+							DocString:  "// B has a docstring",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "legacy plus build lines are combined",
+			Sources: map[string][]byte{
+				"a.go": []byte("// +build linux darwin\n// +build !cgo\n\npackage a\n"),
+			},
+			PkgImportPath: "myimport/a",
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "a.go",
+					PackageName:       "a",
+					PackageImportPath: "myimport/a",
+					BuildConstraint:   "(linux || darwin) && !cgo",
+				},
+			},
+		},
+		{
+			Name: "build constraint directly before package is ignored",
+			Sources: map[string][]byte{
+				"a.go": []byte("//go:build linux\npackage a\n"),
+			},
+			PkgImportPath: "myimport/a",
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "a.go",
+					PackageName:       "a",
+					PackageImportPath: "myimport/a",
+				},
+			},
+		},
+		{
+			Name: "files are filtered by build context",
+			Sources: map[string][]byte{
+				"a.go":         []byte("//go:build linux\n\npackage a\n"),
+				"b_windows.go": []byte("package a\n"),
+				"c.go":         []byte("//go:build windows\n\npackage a\n"),
+			},
+			PkgImportPath: "myimport/a",
+			ParseOptions: []gopkg.ParseOption{
+				gopkg.ParseWithBuildContext(buildContext("windows", "amd64")),
+			},
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "b_windows.go",
+					PackageName:       "a",
+					PackageImportPath: "myimport/a",
+				},
+				{
+					Filepath:          "c.go",
+					PackageName:       "a",
+					PackageImportPath: "myimport/a",
+					BuildConstraint:   "windows",
+				},
+			},
+		},
+		{
+			Name: "directives are separated from doc strings",
+			Sources: map[string][]byte{
+				"a.go": []byte(`package a
+
+// Hello is embedded
+//
+//go:embed hello.txt
+var Hello string
+
+//go:noinline
+func F() {}
+
+// T has a directive before its doc
+//go:generate echo T
+// which is kept
+type T int
+`),
+			},
+			PkgImportPath: "myimport/a",
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "a.go",
+					PackageName:       "a",
+					PackageImportPath: "myimport/a",
+					Vars: []gopkg.DeclVar{
+						{
+							Name:       "Hello",
+							Import:     "myimport/a",
+							Type:       gopkg.TypeString{},
+							DocString:  "// Hello is embedded",
+							Directives: []string{"//go:embed hello.txt"},
+						},
+					},
+					Types: []gopkg.DeclType{
+						{
+							Name:       "T",
+							Import:     "myimport/a",
+							Type:       gopkg.TypeInt{},
+							DocString:  "// T has a directive before its doc\n// which is kept",
+							Directives: []string{"//go:generate echo T"},
+						},
+					},
+					Functions: []gopkg.DeclFunc{
+						{
+							Name:       "F",
+							Import:     "myimport/a",
+							Directives: []string{"//go:noinline"},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "test files are marked and grouped after their package",
+			Sources: map[string][]byte{
+				"a_test.go":          []byte("package a_test\n\nvar T int\n"),
+				"a_internal_test.go": []byte("package a\n\nvar I int\n"),
+				"b.go":               []byte("package a\n\nvar B int\n"),
+			},
+			PkgImportPath: "myimport/a",
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "a_internal_test.go",
+					PackageName:       "a",
+					PackageImportPath: "myimport/a",
+					IsTest:            true,
+					Vars: []gopkg.DeclVar{
+						{Name: "I", Import: "myimport/a", Type: gopkg.TypeInt{}},
+					},
+				},
+				{
+					Filepath:          "b.go",
+					PackageName:       "a",
+					PackageImportPath: "myimport/a",
+					Vars: []gopkg.DeclVar{
+						{Name: "B", Import: "myimport/a", Type: gopkg.TypeInt{}},
+					},
+				},
+				{
+					Filepath:          "a_test.go",
+					PackageName:       "a_test",
+					PackageImportPath: "myimport/a_test",
+					IsTest:            true,
+					Vars: []gopkg.DeclVar{
+						{Name: "T", Import: "myimport/a_test", Type: gopkg.TypeInt{}},
+					},
+				},
+			},
+		},
+		{
+			Name: "test files can be excluded",
+			Sources: map[string][]byte{
+				"a_test.go": []byte("package a_test\n\nvar T int\n"),
+				"b.go":      []byte("package a\n\nvar B int\n"),
+			},
+			PkgImportPath: "myimport/a",
+			ParseOptions: []gopkg.ParseOption{
+				gopkg.ParseExcludeTests(),
+			},
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "b.go",
+					PackageName:       "a",
+					PackageImportPath: "myimport/a",
+					Vars: []gopkg.DeclVar{
+						{Name: "B", Import: "myimport/a", Type: gopkg.TypeInt{}},
+					},
+				},
+			},
+		},
+		{
+			Name: "generated header adjacent to package doc is not in doc string",
+			Sources: map[string][]byte{
+				"a.go": []byte(`// Code generated by mygen. DO NOT EDIT.
+// Package a does things
+package a
+`),
+			},
+			PkgImportPath: "myimport/a",
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "a.go",
+					PackageName:       "a",
+					PackageImportPath: "myimport/a",
+					GeneratedBy:       "mygen",
+					DocString:         "// Package a does things",
+				},
+			},
+		},
+		{
+			Name: "generator is taken from stringer style header",
+			Sources: map[string][]byte{
+				"a.go": []byte(`// Code generated by "stringer -type=Pill"; DO NOT EDIT.
+
+package a
+`),
+			},
+			PkgImportPath: "myimport/a",
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "a.go",
+					PackageName:       "a",
+					PackageImportPath: "myimport/a",
+					GeneratedBy:       `"stringer -type=Pill"`,
+				},
+			},
+		},
+		{
+			Name: "named types are linked to their declarations across files",
+			Sources: map[string][]byte{
+				"a.go": []byte(`package a
+
+type Colour int
+
+type Node struct {
+	Next *Node
+	Colour Colour
+}
+`),
+				"b.go": []byte(`package a
+
+func New() (Node, Colour, error) {
+	return Node{}, 0, nil
+}
+`),
+			},
+			PkgImportPath: "myimport/a",
+			Expected: []gopkg.FileContents{
+				{
+					Filepath:          "a.go",
+					PackageName:       "a",
+					PackageImportPath: "myimport/a",
+					Types: []gopkg.DeclType{
+						{
+							Name:   "Colour",
+							Import: "myimport/a",
+							Type:   gopkg.TypeInt{},
+						},
+						{
+							Name:   "Node",
+							Import: "myimport/a",
+							Type: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{
+										Name: "Next",
+										Type: gopkg.TypePointer{
+											ValueType: gopkg.TypeNamed{
+												Name:   "Node",
+												Import: "myimport/a",
+											},
+										},
+									},
+									{
+										Name: "Colour",
+										Type: gopkg.TypeNamed{
+											Name:      "Colour",
+											Import:    "myimport/a",
+											ValueType: gopkg.TypeInt{},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Filepath:          "b.go",
+					PackageName:       "a",
+					PackageImportPath: "myimport/a",
+					Functions: []gopkg.DeclFunc{
+						{
+							Name:   "New",
+							Import: "myimport/a",
+							ReturnArgs: tmpl.UnnamedReturnArgs(
+								gopkg.TypeNamed{
+									Name:   "Node",
+									Import: "myimport/a",
+									ValueType: gopkg.TypeStruct{
+										Fields: []gopkg.DeclVar{
+											{
+												Name: "Next",
+												Type: gopkg.TypePointer{
+													ValueType: gopkg.TypeNamed{
+														Name:   "Node",
+														Import: "myimport/a",
+													},
+												},
+											},
+											{
+												Name: "Colour",
+												Type: gopkg.TypeNamed{
+													Name:      "Colour",
+													Import:    "myimport/a",
+													ValueType: gopkg.TypeInt{},
+												},
+											},
+										},
+									},
+								},
+								gopkg.TypeNamed{
+									Name:      "Colour",
+									Import:    "myimport/a",
+									ValueType: gopkg.TypeInt{},
+								},
+								gopkg.TypeError{},
+							),
+							BodyTmpl: "\n\treturn Node{}, 0, nil\n",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			actual, err := gopkg.ParseSources(test.Sources, test.PkgImportPath, test.ParseOptions...)
+
+			if test.ExpectedErr != "" {
+				require.EqualError(t, err, test.ExpectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.Expected, actual)
+		})
+	}
+}
+
+func TestParseSource(t *testing.T) {
+
+	actual, err := gopkg.ParseSource(
+		"snippet.go",
+		[]byte("package snippet\n\nvar A int\n"),
+		"myimport/snippet",
+	)
+	require.NoError(t, err)
+
+	require.Equal(
+		t,
+		[]gopkg.FileContents{
+			{
+				Filepath:          "snippet.go",
+				PackageName:       "snippet",
+				PackageImportPath: "myimport/snippet",
+				Vars: []gopkg.DeclVar{
+					{
+						Name:   "A",
+						Import: "myimport/snippet",
+						Type:   gopkg.TypeInt{},
+					},
+				},
+			},
+		},
+		actual,
+	)
+}
+
+//go:embed test_packages/const_groups test_packages/non_declaritive_elements test_packages/very_simple/very_simple.go
+var embeddedTestPackages embed.FS
+
+func TestParseFromFS(t *testing.T) {
+
+	testCases := []struct {
+		Name      string
+		InputPath string
+	}{
+		{
+			Name:      "package directory",
+			InputPath: "test_packages/non_declaritive_elements",
+		},
+		{
+			Name:      "package with const groups",
+			InputPath: "test_packages/const_groups",
+		},
+		{
+			Name:      "single file",
+			InputPath: "test_packages/very_simple/very_simple.go",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			expected, err := gopkg.Parse(
+				test.InputPath,
+				gopkg.ParseWithPkgImportPath("myimport/pkg"),
+			)
+			require.NoError(t, err)
+
+			actual, err := gopkg.Parse(
+				test.InputPath,
+				gopkg.ParseFromFS(embeddedTestPackages),
+				gopkg.ParseWithPkgImportPath("myimport/pkg"),
+			)
+			require.NoError(t, err)
+
+			require.Equal(t, expected, actual)
+		})
+	}
+
+	t.Run("without package import path returns error", func(t *testing.T) {
+		_, err := gopkg.Parse(
+			"test_packages/const_groups",
+			gopkg.ParseFromFS(embeddedTestPackages),
+		)
+		require.EqualError(
+			t,
+			err,
+			"the package import path must be set with ParseWithPkgImportPath when parsing from an fs.FS",
+		)
+	})
+}
+
+func TestParseSingleFileLinksTypesFromPackage(t *testing.T) {
+
+	fsys := fstest.MapFS{
+		"mypkg/a.go": &fstest.MapFile{
+			Data: []byte("package mypkg\n\nvar Default Colour\n"),
+		},
+		"mypkg/b.go": &fstest.MapFile{
+			Data: []byte("package mypkg\n\ntype Colour int\n"),
+		},
+		"mypkg/aa_other.go": &fstest.MapFile{
+			Data: []byte("package otherpkg\n\ntype Colour string\n"),
+		},
+	}
+
+	pkg, err := gopkg.Parse(
+		"mypkg/a.go",
+		gopkg.ParseFromFS(fsys),
+		gopkg.ParseWithPkgImportPath("some/mypkg"),
+	)
+	require.NoError(t, err)
+
+	require.Equal(
+		t,
+		[]gopkg.FileContents{
+			{
+				Filepath:          "mypkg/a.go",
+				PackageName:       "mypkg",
+				PackageImportPath: "some/mypkg",
+				Vars: []gopkg.DeclVar{
+					{
+						Name:   "Default",
+						Import: "some/mypkg",
+						Type: gopkg.TypeNamed{
+							Name:      "Colour",
+							Import:    "some/mypkg",
+							ValueType: gopkg.TypeInt{},
+						},
+					},
+				},
+			},
+		},
+		pkg,
+	)
+}
+
+func TestParseSingleFileOnlyParsesTypesOfSiblingFiles(t *testing.T) {
+
+	fsys := fstest.MapFS{
+		"mypkg/a.go": &fstest.MapFile{
+			Data: []byte("package mypkg\n\nvar Default Colour\n"),
+		},
+		"mypkg/b.go": &fstest.MapFile{
+			Data: []byte("package mypkg\n\nimport . \"example.invalid/x\"\n\ntype Colour int\n\nvar M Matcher\n"),
+		},
+		"mypkg/a_test.go": &fstest.MapFile{
+			Data: []byte("package mypkg_test\n\nimport . \"example.invalid/y\"\n\nvar N Matcher\n"),
+		},
+	}
+
+	pkg, err := gopkg.Parse(
+		"mypkg/a.go",
+		gopkg.ParseFromFS(fsys),
+		gopkg.ParseWithPkgImportPath("some/mypkg"),
+		gopkg.ParseStrictDotImports(),
+	)
+	require.NoError(t, err)
+
+	require.Equal(
+		t,
+		[]gopkg.FileContents{
+			{
+				Filepath:          "mypkg/a.go",
+				PackageName:       "mypkg",
+				PackageImportPath: "some/mypkg",
+				Vars: []gopkg.DeclVar{
+					{
+						Name:   "Default",
+						Import: "some/mypkg",
+						Type: gopkg.TypeNamed{
+							Name:      "Colour",
+							Import:    "some/mypkg",
+							ValueType: gopkg.TypeInt{},
+						},
+					},
+				},
+			},
+		},
+		pkg,
+	)
+}
+
+func TestParseDependentTypes(t *testing.T) {
+
+	t.Run("package with methods", func(t *testing.T) {
+
+		pkg, err := gopkg.Parse(
+			"test_packages/method_sets",
+			gopkg.ParseWithPkgImportPath("some/import/method_sets"),
+			gopkg.ParseDependentTypes(),
+		)
+		require.NoError(t, err)
+
+		var receivers []string
+		for _, f := range pkg {
+			for _, fn := range f.Functions {
+				receivers = append(receivers, fn.Receiver.TypeName+"."+fn.Name)
+			}
+		}
+		require.Equal(
+			t,
+			[]string{
+				"Named.Name",
+				"Named.SetName",
+				"Counter.Count",
+				"Counter.Name",
+				"Counter.Inc",
+				"Service.Start",
+			},
+			receivers,
+		)
+	})
+
+	t.Run("sources resolve types from the current package", func(t *testing.T) {
+
+		pkg, err := gopkg.ParseSources(
+			map[string][]byte{
+				"a.go": []byte("package a\n\ntype A struct {\n\tB B\n}\n"),
+				"b.go": []byte("package a\n\ntype B int\n"),
+			},
+			"myimport/a",
+			gopkg.ParseDependentTypes(),
+		)
+		require.NoError(t, err)
+
+		require.Equal(
+			t,
+			gopkg.TypeStruct{
+				Fields: []gopkg.DeclVar{
+					{
+						Name: "B",
+						Type: gopkg.TypeNamed{
+							Name:      "B",
+							Import:    "myimport/a",
+							ValueType: gopkg.TypeInt{},
+						},
+					},
+				},
+			},
+			pkg[0].Types[0].Type,
+		)
+	})
+
+	t.Run("diamond shaped dependencies", func(t *testing.T) {
+
+		pkg, err := gopkg.ParseSources(
+			map[string][]byte{
+				"a.go": []byte("package a\n\ntype A struct {\n\tB B\n\tC C\n}\n\ntype B struct {\n\tD D\n}\n\ntype C struct {\n\tD D\n}\n\ntype D int\n"),
+			},
+			"myimport/a",
+			gopkg.ParseDependentTypesDepth(-1),
+		)
+		require.NoError(t, err)
+
+		d := gopkg.TypeNamed{
+			Name:      "D",
+			Import:    "myimport/a",
+			ValueType: gopkg.TypeInt{},
+		}
+		require.Equal(
+			t,
+			gopkg.TypeStruct{
+				Fields: []gopkg.DeclVar{
+					{
+						Name: "B",
+						Type: gopkg.TypeNamed{
+							Name:   "B",
+							Import: "myimport/a",
+							ValueType: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{Name: "D", Type: d},
+								},
+							},
+						},
+					},
+					{
+						Name: "C",
+						Type: gopkg.TypeNamed{
+							Name:   "C",
+							Import: "myimport/a",
+							ValueType: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
+									{Name: "D", Type: d},
+								},
+							},
+						},
+					},
+				},
+			},
+			pkg[0].Types[0].Type,
+		)
+	})
+
+	t.Run("types referred to at different depths are resolved to each depth", func(t *testing.T) {
+
+		pkg, err := gopkg.ParseSources(
+			map[string][]byte{
+				"a.go": []byte("package a\n\ntype A struct {\n\tB B\n\tC C\n}\n\ntype B struct {\n\tC C\n}\n\ntype C struct {\n\tD D\n}\n\ntype D int\n"),
 			},
-			Expected: []gopkg.FileContents{
-				{
-					Filepath:          "test_packages/dependent_types/dependent_types.go",
-					PackageName:       "dependent_types",
-					PackageImportPath: "myimport/some_dependent_types",
-					Imports: tmpl.UnnamedImports(
-						"math/big",
-						"github.com/shopspring/decimal",
-						"github.com/thecodedproject/gopkg/test_packages/dependent_types/nested_pkg",
-					),
-					Types: []gopkg.DeclType{
-						{
-							Name: "AStruct",
-							Import: "myimport/some_dependent_types",
-							Type: gopkg.TypeStruct{
+			"myimport/a",
+			gopkg.ParseDependentTypesDepth(2),
+		)
+		require.NoError(t, err)
+
+		require.Equal(
+			t,
+			gopkg.TypeStruct{
+				Fields: []gopkg.DeclVar{
+					{
+						Name: "B",
+						Type: gopkg.TypeNamed{
+							Name:   "B",
+							Import: "myimport/a",
+							ValueType: gopkg.TypeStruct{
 								Fields: []gopkg.DeclVar{
 									{
-										Name: "One",
+										Name: "C",
 										Type: gopkg.TypeNamed{
-											Name: "Int",
-											Import: "math/big",
+											Name:   "C",
+											Import: "myimport/a",
 											ValueType: gopkg.TypeStruct{
 												Fields: []gopkg.DeclVar{
 													{
-														Name: "neg",
-														Type: gopkg.TypeBool{},
-													},
-													{
-														Name: "abs",
+														Name: "D",
 														Type: gopkg.TypeNamed{
-															Name: "nat",
-															Import: "math/big",
-														},
-													},
-												},
-											},
-										},
-									},
-									{
-										Name: "Two",
-										Type: gopkg.TypeNamed{
-											Name: "Decimal",
-											Import: "github.com/shopspring/decimal",
-											ValueType: gopkg.TypeStruct{
-												Fields: []gopkg.DeclVar{
-													{
-														Name: "value",
-														Type: gopkg.TypePointer{
-															ValueType: gopkg.TypeNamed{
-																Name: "Int",
-																Import: "math/big",
-															},
+															Name:   "D",
+															Import: "myimport/a",
 														},
 													},
-													{
-														Name: "exp",
-														Type: gopkg.TypeInt32{},
-													},
 												},
 											},
 										},
 									},
+								},
+							},
+						},
+					},
+					{
+						Name: "C",
+						Type: gopkg.TypeNamed{
+							Name:   "C",
+							Import: "myimport/a",
+							ValueType: gopkg.TypeStruct{
+								Fields: []gopkg.DeclVar{
 									{
-										Name: "Three",
-										Type: gopkg.TypeNamed{
-											Name: "SomeType",
-											Import: "github.com/thecodedproject/gopkg/test_packages/dependent_types/nested_pkg",
-											ValueType: gopkg.TypePointer{
-												ValueType: gopkg.TypeInt32{},
-											},
-										},
-									},
-									{
-										Name: "Four",
+										Name: "D",
 										Type: gopkg.TypeNamed{
-											Name: "AnotherType",
-											Import: "github.com/thecodedproject/gopkg/test_packages/dependent_types/nested_pkg",
-											ValueType: gopkg.TypeStruct{
-												Fields: []gopkg.DeclVar{
-													{
-														Name: "A",
-														Type: gopkg.TypeString{},
-													},
-													{
-														Name: "B",
-														Type: gopkg.TypeNamed{
-															Name: "Context",
-															Import: "context",
-														},
-													},
-												},
-											},
+											Name:      "D",
+											Import:    "myimport/a",
+											ValueType: gopkg.TypeInt{},
 										},
 									},
 								},
@@ -1597,68 +3832,469 @@ func TestParseDir(t *testing.T) {
 					},
 				},
 			},
+			pkg[0].Types[0].Type,
+		)
+	})
+
+	t.Run("deep diamond shaped dependencies are resolved once", func(t *testing.T) {
+
+		// Each type refers to the next twice, so resolving every reference
+		// separately would take 2^depth steps
+		const depth = 32
+
+		var src strings.Builder
+		src.WriteString("package a\n")
+		for i := 0; i < depth; i++ {
+			fmt.Fprintf(&src, "\ntype T%d struct {\n\tX T%d\n\tY T%d\n}\n", i, i+1, i+1)
+		}
+		fmt.Fprintf(&src, "\ntype T%d int\n", depth)
+
+		pkg, err := gopkg.ParseSources(
+			map[string][]byte{
+				"a.go": []byte(src.String()),
+			},
+			"myimport/a",
+			gopkg.ParseDependentTypesDepth(-1),
+		)
+		require.NoError(t, err)
+
+		typ := pkg[0].Types[0].Type
+		for i := 1; i <= depth; i++ {
+			s, ok := typ.(gopkg.TypeStruct)
+			require.True(t, ok)
+			require.Len(t, s.Fields, 2)
+
+			named, ok := s.Fields[1].Type.(gopkg.TypeNamed)
+			require.True(t, ok)
+			require.Equal(t, fmt.Sprintf("T%d", i), named.Name)
+
+			typ = named.ValueType
+		}
+		require.Equal(t, gopkg.TypeInt{}, typ)
+	})
+}
+
+func TestParseDotImports(t *testing.T) {
+
+	t.Run("dot imports are resolved from the parsed directory", func(t *testing.T) {
+
+		expected, err := gopkg.Parse(
+			"test_packages/dot_and_blank_imports",
+			gopkg.ParseWithPkgImportPath("myimport/dot_and_blank_imports"),
+		)
+		require.NoError(t, err)
+
+		pkgDir, err := filepath.Abs("test_packages/dot_and_blank_imports")
+		require.NoError(t, err)
+
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		require.NoError(t, os.Chdir(t.TempDir()))
+		defer os.Chdir(wd)
+
+		actual, err := gopkg.Parse(
+			pkgDir,
+			gopkg.ParseWithPkgImportPath("myimport/dot_and_blank_imports"),
+		)
+		require.NoError(t, err)
+
+		require.Equal(t, expected[0].Types, actual[0].Types)
+	})
+
+	unloadableSrc := []byte("package a\n\nimport . \"github.com/thecodedproject/gopkg/does_not_exist\"\n\nvar M Matcher\n")
+
+	t.Run("dot import which cannot be loaded leaves identifiers unqualified", func(t *testing.T) {
+
+		pkg, err := gopkg.ParseSource(
+			"a.go",
+			unloadableSrc,
+			"myimport/a",
+		)
+		require.NoError(t, err)
+
+		require.Equal(
+			t,
+			[]gopkg.DeclVar{
+				{
+					Name:   "M",
+					Import: "myimport/a",
+					Type: gopkg.TypeNamed{
+						Name:   "Matcher",
+						Import: "myimport/a",
+					},
+				},
+			},
+			pkg[0].Vars,
+		)
+	})
+
+	t.Run("dot import which cannot be loaded with strict dot imports returns error", func(t *testing.T) {
+
+		_, err := gopkg.ParseSource(
+			"a.go",
+			unloadableSrc,
+			"myimport/a",
+			gopkg.ParseStrictDotImports(),
+		)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to load dot imported package")
+	})
+}
+
+func TestParseTypeChecked(t *testing.T) {
+
+	importPath := "some/import/type_checked"
+
+	colour := gopkg.TypeNamed{
+		Name:      "Colour",
+		Import:    importPath,
+		ValueType: gopkg.TypeInt{},
+		Methods: []gopkg.DeclFunc{
+			{
+				Name:   "String",
+				Import: importPath,
+				Receiver: gopkg.FuncReceiver{
+					VarName:  "c",
+					TypeName: "Colour",
+				},
+				ReturnArgs: tmpl.UnnamedReturnArgs(
+					gopkg.TypeString{},
+				),
+			},
 		},
 	}
 
-	for _, test := range testCases {
-		t.Run(test.Name, func(t *testing.T) {
-
-			pc, err := gopkg.Parse(test.PkgDir, test.ParseOptions...)
-			require.NoError(t, err)
+	pointFields := []gopkg.DeclVar{
+		{
+			Name: "X",
+			Type: gopkg.TypeInt{},
+		},
+		{
+			Name: "Y",
+			Type: gopkg.TypeInt{},
+		},
+	}
 
-			assert.Equal(t, test.Expected, pc)
-		})
+	point := gopkg.TypeNamed{
+		Name:   "Point",
+		Import: importPath,
+		ValueType: gopkg.TypeStruct{
+			Fields: pointFields,
+		},
+		Methods: []gopkg.DeclFunc{
+			{
+				Name:   "Move",
+				Import: importPath,
+				Receiver: gopkg.FuncReceiver{
+					VarName:   "p",
+					TypeName:  "Point",
+					IsPointer: true,
+				},
+				Args: []gopkg.DeclVar{
+					{
+						Name: "dx",
+						Type: gopkg.TypeInt{},
+					},
+					{
+						Name: "dys",
+						Type: gopkg.TypeInt{},
+					},
+				},
+				VariadicLastArg: true,
+			},
+		},
 	}
-}
 
-func TestParseSingleFile(t *testing.T) {
+	writer := gopkg.TypeNamed{
+		Name:   "Writer",
+		Import: "io",
+		ValueType: gopkg.TypeInterface{
+			Funcs: []gopkg.DeclFunc{
+				{
+					Name: "Write",
+					Args: []gopkg.DeclVar{
+						{
+							Name: "p",
+							Type: gopkg.TypeArray{
+								ValueType: gopkg.TypeByte{},
+							},
+						},
+					},
+					ReturnArgs: []gopkg.DeclVar{
+						{
+							Name: "n",
+							Type: gopkg.TypeInt{},
+						},
+						{
+							Name: "err",
+							Type: gopkg.TypeError{},
+						},
+					},
+				},
+			},
+		},
+	}
 
-	testCases := []struct{
-		Name string
-		InputFile string
-		ParseOptions []gopkg.ParseOption
-		Expected []gopkg.FileContents
-	}{
+	expected := []gopkg.FileContents{
 		{
-			Name: "very_simple/very_simple.go",
-			InputFile: "test_packages/very_simple/very_simple.go",
-			Expected: []gopkg.FileContents{
+			Filepath:          "test_packages/type_checked/shapes.go",
+			PackageName:       "type_checked",
+			PackageImportPath: importPath,
+			Imports: []gopkg.ImportAndAlias{
 				{
-					Filepath:          "test_packages/very_simple/very_simple.go",
-					PackageName:       "very_simple",
-					PackageImportPath: "github.com/thecodedproject/gopkg/test_packages/very_simple",
-					Vars: []gopkg.DeclVar{
+					Import: "io",
+				},
+			},
+			Types: []gopkg.DeclType{
+				{
+					Name:   "Colour",
+					Import: importPath,
+					Type:   gopkg.TypeInt{},
+				},
+				{
+					Name:   "Point",
+					Import: importPath,
+					Type: gopkg.TypeStruct{
+						Fields: pointFields,
+					},
+				},
+				{
+					Name:   "Shape",
+					Import: importPath,
+					Type: gopkg.TypeStruct{
+						Fields: []gopkg.DeclVar{
+							{
+								Name: "Colour",
+								Type: colour,
+							},
+							{
+								Name: "Centre",
+								Type: gopkg.TypePointer{
+									ValueType: point,
+								},
+							},
+							{
+								Name: "Out",
+								Type: writer,
+							},
+						},
+					},
+				},
+			},
+			Functions: []gopkg.DeclFunc{
+				{
+					Name:   "String",
+					Import: importPath,
+					Receiver: gopkg.FuncReceiver{
+						VarName:  "c",
+						TypeName: colour.Name,
+					},
+					ReturnArgs: tmpl.UnnamedReturnArgs(
+						gopkg.TypeString{},
+					),
+					BodyTmpl: "\n\treturn \"colour\"\n",
+				},
+				{
+					Name:   "Move",
+					Import: importPath,
+					Receiver: gopkg.FuncReceiver{
+						VarName:   "p",
+						TypeName:  point.Name,
+						IsPointer: true,
+					},
+					Args: []gopkg.DeclVar{
 						{
-							Name:   "MyVar",
-							Import: "github.com/thecodedproject/gopkg/test_packages/very_simple",
-							Type:   gopkg.TypeInt{},
+							Name: "dx",
+							Type: gopkg.TypeInt{},
+						},
+						{
+							Name: "dys",
+							Type: gopkg.TypeInt{},
 						},
 					},
+					VariadicLastArg: true,
 				},
 			},
 		},
 	}
 
-	for _, test := range testCases {
-		t.Run(test.Name, func(t *testing.T) {
-			pc, err := gopkg.Parse(test.InputFile, test.ParseOptions...)
+	actual, err := gopkg.Parse(
+		"test_packages/type_checked",
+		gopkg.ParseTypeChecked(),
+		gopkg.ParseWithPkgImportPath(importPath),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, actual)
+
+	t.Run("parsing sources returns error", func(t *testing.T) {
+		_, err := gopkg.ParseSources(
+			map[string][]byte{"a.go": []byte("package a\n")},
+			"myimport/a",
+			gopkg.ParseTypeChecked(),
+		)
+		require.EqualError(t, err, "ParseTypeChecked is not supported when parsing sources")
+	})
+}
+
+func TestParseTypeCheckedAliases(t *testing.T) {
+
+	importPath := "some/import/type_checked_aliases"
+
+	colour := gopkg.TypeNamed{
+		Name:      "Colour",
+		Import:    importPath,
+		ValueType: gopkg.TypeInt{},
+	}
+
+	tagged := gopkg.TypeStruct{
+		Fields: []gopkg.DeclVar{
+			{
+				Name: "Label",
+				Type: gopkg.TypeNamed{
+					Name:      "Label",
+					Import:    importPath,
+					ValueType: gopkg.TypeString{},
+				},
+			},
+			{
+				Name: "Colour",
+				Type: gopkg.TypeNamed{
+					Name:      "ColourAlias",
+					Import:    importPath,
+					ValueType: gopkg.TypeInt{},
+				},
+			},
+			{
+				Name: "Value",
+				Type: gopkg.TypeAny{},
+			},
+		},
+	}
+
+	// Named types within a ValueType are not expanded, and aliases within
+	// them are replaced by the type which they alias
+	taggedValueType := gopkg.TypeStruct{
+		Fields: []gopkg.DeclVar{
+			{
+				Name: "Label",
+				Type: gopkg.TypeString{},
+			},
+			{
+				Name: "Colour",
+				Type: gopkg.TypeNamed{
+					Name:   "Colour",
+					Import: importPath,
+				},
+			},
+			{
+				Name: "Value",
+				Type: gopkg.TypeAny{},
+			},
+		},
+	}
+
+	expectedTypes := []gopkg.DeclType{
+		{
+			Name:   "Colour",
+			Import: importPath,
+			Type:   gopkg.TypeInt{},
+		},
+		{
+			Name:    "Label",
+			Import:  importPath,
+			Type:    gopkg.TypeString{},
+			IsAlias: true,
+		},
+		{
+			Name:    "ColourAlias",
+			Import:  importPath,
+			Type:    colour,
+			IsAlias: true,
+		},
+		{
+			Name:   "Tagged",
+			Import: importPath,
+			Type:   tagged,
+		},
+		{
+			Name:   "Wrapper",
+			Import: importPath,
+			Type: gopkg.TypeStruct{
+				Fields: []gopkg.DeclVar{
+					{
+						Name: "Tagged",
+						Type: gopkg.TypeNamed{
+							Name:      "Tagged",
+							Import:    importPath,
+							ValueType: taggedValueType,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, gotypesalias := range []string{"0", "1"} {
+		t.Run("gotypesalias="+gotypesalias, func(t *testing.T) {
+
+			t.Setenv("GODEBUG", "gotypesalias="+gotypesalias)
+
+			actual, err := gopkg.Parse(
+				"test_packages/type_checked_aliases",
+				gopkg.ParseTypeChecked(),
+				gopkg.ParseWithPkgImportPath(importPath),
+			)
 			require.NoError(t, err)
-			assert.Equal(t, test.Expected, pc)
+			require.Len(t, actual, 1)
+
+			assert.Equal(t, expectedTypes, actual[0].Types)
 		})
 	}
 }
 
-// TestParseAndWriteSingleFile checks that a roundtrip (parse + generate) of a single produces the desired result
 func TestParseAndWriteSingleFile(t *testing.T) {
 
 	testCases := []struct{
 		Name string
 		InputFile string
 	}{
+		{
+			Name: "const_groups",
+			InputFile: "test_packages/const_groups/enums.go",
+		},
+		{
+			Name: "const_specs",
+			InputFile: "testdata/TestParseAndWriteSingleFile/const_specs_input.go",
+		},
 		{
 			Name: "docstrings",
 			InputFile: "testdata/TestParseAndWriteSingleFile/docstrings_input.go",
 		},
+		{
+			Name: "field_comments",
+			InputFile: "testdata/TestParseAndWriteSingleFile/field_comments_input.go",
+		},
+		{
+			Name: "generics",
+			InputFile: "testdata/TestParseAndWriteSingleFile/generics_input.go",
+		},
+		{
+			Name: "predeclared_types",
+			InputFile: "testdata/TestParseAndWriteSingleFile/predeclared_types_input.go",
+		},
+		{
+			Name: "initializer_expressions",
+			InputFile: "testdata/TestParseAndWriteSingleFile/initializer_expressions_input.go",
+		},
+		{
+			Name: "build_constraints",
+			InputFile: "testdata/TestParseAndWriteSingleFile/build_constraints_input.go",
+		},
+		{
+			Name: "directives",
+			InputFile: "testdata/TestParseAndWriteSingleFile/directives_input.go",
+		},
 	}
 
 	for _, test := range testCases {
@@ -1857,3 +4493,14 @@ func protoTypeFuncs(typeName string, typeIndex string) []gopkg.DeclFunc {
 		},
 	}
 }
+
+const protoConversionFileDescriptorLiteral = `[]byte{
+	// 109 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x4c, 0x49, 0x4d, 0xd3,
+	0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x12, 0x00, 0x53, 0xf1, 0xc9, 0xf9, 0x79, 0x65, 0xa9, 0x45,
+	0xc5, 0x99, 0xf9, 0x79, 0x4a, 0xca, 0x5c, 0xdc, 0x9e, 0x79, 0x25, 0x8e, 0xc5, 0xc1, 0x25, 0x45,
+	0x99, 0x79, 0xe9, 0x42, 0x22, 0x5c, 0xac, 0x65, 0x89, 0x39, 0xa5, 0xa9, 0x12, 0x8c, 0x0a, 0x8c,
+	0x1a, 0x9c, 0x41, 0x10, 0x8e, 0x92, 0x26, 0x97, 0x60, 0x70, 0x46, 0x7e, 0x41, 0x71, 0x01, 0x48,
+	0x8d, 0x4b, 0x6a, 0x72, 0x66, 0x6e, 0x62, 0x0e, 0x76, 0xa5, 0x49, 0x6c, 0x60, 0x1b, 0x8c, 0x01,
+	0x01, 0x00, 0x00, 0xff, 0xff, 0xcc, 0x3d, 0x4c, 0x72, 0x75, 0x00, 0x00, 0x00,
+}`
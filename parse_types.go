@@ -0,0 +1,474 @@
+package gopkg
+
+import (
+	"go/ast"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/packages"
+)
+
+// typeCheckedPackage holds the type information of a package loaded with
+// `go/packages` (see ParseTypeChecked)
+//
+// Parse builds its own syntax trees, so the objects from the type checker are
+// indexed by the file and offset of the identifiers which refer to them.
+type typeCheckedPackage struct {
+	// pkgPath is the path of the package as loaded by `go/packages`, which
+	// may differ from the import path set with ParseWithPkgImportPath
+	pkgPath string
+
+	// importPath is the import path which types declared in the package are
+	// given
+	importPath string
+
+	// objects maps the absolute path of each file in the package to the
+	// objects used in that file, by the offset of the identifier which uses
+	// them
+	objects map[string]map[int]types.Object
+}
+
+// loadTypeCheckedPackage loads the package containing `inputPath` (either a
+// package directory or a single file) with full type information
+func loadTypeCheckedPackage(
+	inputPath string,
+	parseOpts parseOptions,
+) (*typeCheckedPackage, error) {
+
+	if parseOpts.fsys != nil {
+		return nil, errors.New("ParseTypeChecked is not supported when parsing from an fs.FS")
+	}
+
+	dir := inputPath
+	fileInfo, err := os.Stat(inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fileInfo.IsDir() {
+		dir = filepath.Dir(inputPath)
+	}
+
+	conf := &packages.Config{
+		Mode: packages.NeedName |
+			packages.NeedFiles |
+			packages.NeedSyntax |
+			packages.NeedTypes |
+			packages.NeedTypesInfo,
+		Dir:   dir,
+		Tests: !parseOpts.excludeTests,
+	}
+
+	if ctxt := parseOpts.buildContext; ctxt != nil {
+		conf.Env = append(os.Environ(), "GOOS="+ctxt.GOOS, "GOARCH="+ctxt.GOARCH)
+		if len(ctxt.BuildTags) > 0 {
+			conf.BuildFlags = []string{"-tags=" + strings.Join(ctxt.BuildTags, ",")}
+		}
+	}
+
+	pkgs, err := packages.Load(conf, ".")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load package for type checking")
+	}
+
+	p := &typeCheckedPackage{
+		importPath: parseOpts.pkgImportPath,
+		objects:    make(map[string]map[int]types.Object),
+	}
+
+	for _, pkg := range pkgs {
+
+		// The test binary's generated main package is not part of the parsed
+		// package
+		if strings.HasSuffix(pkg.PkgPath, ".test") {
+			continue
+		}
+
+		if len(pkg.Errors) > 0 {
+			return nil, errors.Wrap(pkg.Errors[0], "failed to type check package '"+pkg.PkgPath+"'")
+		}
+
+		if !strings.HasSuffix(pkg.PkgPath, "_test") {
+			p.pkgPath = pkg.PkgPath
+		}
+
+		for ident, obj := range pkg.TypesInfo.Uses {
+			pos := pkg.Fset.Position(ident.Pos())
+
+			fileObjects, ok := p.objects[pos.Filename]
+			if !ok {
+				fileObjects = make(map[int]types.Object)
+				p.objects[pos.Filename] = fileObjects
+			}
+			fileObjects[pos.Offset] = obj
+		}
+	}
+
+	return p, nil
+}
+
+// fileObjects returns the objects used in the file at `filename`, by the
+// offset of the identifier which uses them
+func (p *typeCheckedPackage) fileObjects(filename string) map[int]types.Object {
+
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		return nil
+	}
+	return p.objects[absPath]
+}
+
+// typeNameAt returns the type name object referred to by `ident` in the file
+// currently being parsed
+//
+// Returns nil if no type information is available for `ident`.
+func typeNameAt(parseOpts parseOptions, ident *ast.Ident) *types.TypeName {
+
+	if parseOpts.fileObjects == nil {
+		return nil
+	}
+
+	file := parseOpts.fileSet.File(ident.Pos())
+	if file == nil {
+		return nil
+	}
+
+	typeName, _ := parseOpts.fileObjects[file.Offset(ident.Pos())].(*types.TypeName)
+	return typeName
+}
+
+// importPathOf returns the import path of `pkg`, as it is given in the parsed
+// FileContents
+func (p *typeCheckedPackage) importPathOf(pkg *types.Package) string {
+
+	if pkg == nil {
+		return ""
+	}
+
+	switch pkg.Path() {
+	case p.pkgPath:
+		return p.importPath
+	case p.pkgPath + "_test":
+		return p.importPath + "_test"
+	}
+	return pkg.Path()
+}
+
+// namedTypeFromTypeName returns the TypeNamed for `typeName`, with the
+// underlying type as its ValueType and the methods declared on it
+func (p *typeCheckedPackage) namedTypeFromTypeName(
+	typeName *types.TypeName,
+) (TypeNamed, error) {
+
+	valueType, err := p.typeFromTypesType(typeName.Type().Underlying())
+	if err != nil {
+		return TypeNamed{}, err
+	}
+
+	named := TypeNamed{
+		Name:      typeName.Name(),
+		Import:    p.importPathOf(typeName.Pkg()),
+		ValueType: valueType,
+	}
+
+	if n, ok := typeName.Type().(*types.Named); ok && n.Obj() == typeName {
+		named.Methods, err = p.methodsOf(n)
+		if err != nil {
+			return TypeNamed{}, err
+		}
+	}
+
+	return named, nil
+}
+
+// methodsOf returns the methods declared on `named` (not including any
+// methods of an interface type or promoted from embedded fields)
+func (p *typeCheckedPackage) methodsOf(named *types.Named) ([]DeclFunc, error) {
+
+	var typeParams []string
+	for i := 0; i < named.TypeParams().Len(); i++ {
+		typeParams = append(typeParams, named.TypeParams().At(i).Obj().Name())
+	}
+
+	var methods []DeclFunc
+	for i := 0; i < named.NumMethods(); i++ {
+		m := named.Method(i)
+		sig := m.Type().(*types.Signature)
+
+		f, err := p.funcFromSignature(sig)
+		if err != nil {
+			return nil, err
+		}
+
+		_, isPointer := sig.Recv().Type().(*types.Pointer)
+
+		methods = append(methods, DeclFunc{
+			Name:   m.Name(),
+			Import: p.importPathOf(m.Pkg()),
+			Receiver: FuncReceiver{
+				VarName:    sig.Recv().Name(),
+				TypeName:   named.Obj().Name(),
+				IsPointer:  isPointer,
+				TypeParams: typeParams,
+			},
+			Args:            f.Args,
+			ReturnArgs:      f.ReturnArgs,
+			VariadicLastArg: f.VariadicLastArg,
+		})
+	}
+	return methods, nil
+}
+
+// typeFromTypesType converts `t` from `go/types` into its Type
+//
+// Named types within `t` are not expanded; i.e. they have no ValueType.
+func (p *typeCheckedPackage) typeFromTypesType(t types.Type) (Type, error) {
+
+	// As with a named type, an alias within `t` is not expanded (i.e. it is
+	// replaced by the type which it aliases)
+	switch t := unalias(t).(type) {
+	case *types.Basic:
+		if t.Kind() == types.UnsafePointer {
+			return TypeNamed{
+				Name:   "Pointer",
+				Import: "unsafe",
+			}, nil
+		}
+
+		basic := typeFromString(t.Name())
+		if basic == nil {
+			return nil, errors.New("unknown basic type '" + t.Name() + "'")
+		}
+		return basic, nil
+
+	case *types.Named:
+		if t.Obj().Pkg() == nil {
+			// i.e. `error` or `comparable`
+			return typeFromString(t.Obj().Name()), nil
+		}
+
+		named := TypeNamed{
+			Name:   t.Obj().Name(),
+			Import: p.importPathOf(t.Obj().Pkg()),
+		}
+
+		if t.TypeArgs().Len() == 0 {
+			return named, nil
+		}
+
+		typeArgs := make([]Type, 0, t.TypeArgs().Len())
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			typeArg, err := p.typeFromTypesType(t.TypeArgs().At(i))
+			if err != nil {
+				return nil, err
+			}
+			typeArgs = append(typeArgs, typeArg)
+		}
+
+		return TypeGeneric{
+			Name:     named.Name,
+			Import:   named.Import,
+			TypeArgs: typeArgs,
+		}, nil
+
+	case *types.TypeParam:
+		return TypeNamed{
+			Name: t.Obj().Name(),
+		}, nil
+
+	case *types.Pointer:
+		valueType, err := p.typeFromTypesType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return TypePointer{
+			ValueType: valueType,
+		}, nil
+
+	case *types.Slice:
+		valueType, err := p.typeFromTypesType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return TypeArray{
+			ValueType: valueType,
+		}, nil
+
+	case *types.Array:
+		valueType, err := p.typeFromTypesType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return TypeFixedArray{
+			Len:       strconv.FormatInt(t.Len(), 10),
+			ValueType: valueType,
+		}, nil
+
+	case *types.Map:
+		keyType, err := p.typeFromTypesType(t.Key())
+		if err != nil {
+			return nil, err
+		}
+
+		valueType, err := p.typeFromTypesType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return TypeMap{
+			KeyType:   keyType,
+			ValueType: valueType,
+		}, nil
+
+	case *types.Chan:
+		valueType, err := p.typeFromTypesType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		dir := ChanDirBoth
+		switch t.Dir() {
+		case types.SendOnly:
+			dir = ChanDirSend
+		case types.RecvOnly:
+			dir = ChanDirRecv
+		}
+
+		return TypeChan{
+			Dir:       dir,
+			ValueType: valueType,
+		}, nil
+
+	case *types.Signature:
+		return p.funcFromSignature(t)
+
+	case *types.Struct:
+		var s TypeStruct
+		for i := 0; i < t.NumFields(); i++ {
+			field := t.Field(i)
+
+			fieldType, err := p.typeFromTypesType(field.Type())
+			if err != nil {
+				return nil, err
+			}
+
+			if field.Embedded() {
+				s.Embeds = append(s.Embeds, StructEmbed{Type: fieldType})
+				continue
+			}
+
+			s.Fields = append(s.Fields, DeclVar{
+				Name:      field.Name(),
+				Type:      fieldType,
+				StructTag: reflect.StructTag(t.Tag(i)),
+			})
+		}
+		return s, nil
+
+	case *types.Interface:
+		if t == unalias(types.Universe.Lookup("any").Type()) {
+			return TypeAny{}, nil
+		}
+
+		var i TypeInterface
+		for iM := 0; iM < t.NumExplicitMethods(); iM++ {
+			m := t.ExplicitMethod(iM)
+
+			f, err := p.funcFromSignature(m.Type().(*types.Signature))
+			if err != nil {
+				return nil, err
+			}
+
+			i.Funcs = append(i.Funcs, DeclFunc{
+				Name:            m.Name(),
+				Args:            f.Args,
+				ReturnArgs:      f.ReturnArgs,
+				VariadicLastArg: f.VariadicLastArg,
+			})
+		}
+
+		for iE := 0; iE < t.NumEmbeddeds(); iE++ {
+			embed, err := p.typeFromTypesType(t.EmbeddedType(iE))
+			if err != nil {
+				return nil, err
+			}
+			i.Embeds = append(i.Embeds, embed)
+		}
+		return i, nil
+
+	case *types.Union:
+		var u TypeUnion
+		for i := 0; i < t.Len(); i++ {
+			termType, err := p.typeFromTypesType(t.Term(i).Type())
+			if err != nil {
+				return nil, err
+			}
+
+			u.Terms = append(u.Terms, TypeUnionTerm{
+				Type:  termType,
+				Tilde: t.Term(i).Tilde(),
+			})
+		}
+		return u, nil
+	}
+
+	return nil, errors.New("unknown type '" + t.String() + "'")
+}
+
+func (p *typeCheckedPackage) funcFromSignature(sig *types.Signature) (TypeFunc, error) {
+
+	args, err := p.declVarsFromTuple(sig.Params())
+	if err != nil {
+		return TypeFunc{}, err
+	}
+
+	// As when parsing, the type of a variadic arg is the type of each
+	// element (i.e. `T` rather than `[]T`)
+	if sig.Variadic() {
+		last := sig.Params().At(sig.Params().Len() - 1)
+		elemType, err := p.typeFromTypesType(last.Type().(*types.Slice).Elem())
+		if err != nil {
+			return TypeFunc{}, err
+		}
+		args[len(args)-1].Type = elemType
+	}
+
+	retArgs, err := p.declVarsFromTuple(sig.Results())
+	if err != nil {
+		return TypeFunc{}, err
+	}
+
+	return TypeFunc{
+		Args:            args,
+		ReturnArgs:      retArgs,
+		VariadicLastArg: sig.Variadic(),
+	}, nil
+}
+
+func (p *typeCheckedPackage) declVarsFromTuple(tuple *types.Tuple) ([]DeclVar, error) {
+
+	if tuple.Len() == 0 {
+		return nil, nil
+	}
+
+	vars := make([]DeclVar, 0, tuple.Len())
+	for i := 0; i < tuple.Len(); i++ {
+		v := tuple.At(i)
+
+		vType, err := p.typeFromTypesType(v.Type())
+		if err != nil {
+			return nil, err
+		}
+
+		vars = append(vars, DeclVar{
+			Name: v.Name(),
+			Type: vType,
+		})
+	}
+	return vars, nil
+}
@@ -0,0 +1,14 @@
+//go:build go1.22
+
+package gopkg
+
+import (
+	"go/types"
+)
+
+// unalias returns the type aliased by `t` if `t` is an alias (a *types.Alias,
+// which go/types creates with `GODEBUG=gotypesalias=1`; the default for
+// modules using go 1.23 or later); otherwise it returns `t`
+func unalias(t types.Type) types.Type {
+	return types.Unalias(t)
+}
@@ -0,0 +1,12 @@
+//go:build !go1.22
+
+package gopkg
+
+import (
+	"go/types"
+)
+
+// unalias returns `t`, as go/types does not create aliases before go 1.22
+func unalias(t types.Type) types.Type {
+	return t
+}
@@ -0,0 +1,27 @@
+package all_built_in_types
+
+var SomeUint64 uint64 = 10
+
+type SomeOtherStruct struct {
+	SI8  int8
+	SI16 int16
+
+	U   uint
+	U8  uint8
+	U16 uint16
+	U32 uint32
+	U64 uint64
+	UP  uintptr
+
+	R rune
+
+	C64  complex64
+	C128 complex128
+
+	A any
+}
+
+func SomeOtherFunc(a any) (rune, complex128) {
+
+	return 0, 0
+}
@@ -0,0 +1,5 @@
+package build_constraints
+
+func platformName() string {
+	return "linux"
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package build_constraints
+
+func platformName() string {
+	return "other"
+}
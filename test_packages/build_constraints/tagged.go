@@ -0,0 +1,8 @@
+//go:build mytag && (amd64 || arm64)
+// +build mytag
+// +build amd64 arm64
+
+// Package build_constraints has files for different platforms
+package build_constraints
+
+const Tagged = true
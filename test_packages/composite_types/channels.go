@@ -0,0 +1,21 @@
+package composite_types
+
+import (
+	"context"
+)
+
+type MyCustomChan chan<- <-chan int
+
+type SomeChanInterface interface {
+	Jobs() <-chan context.Context
+	Results(r chan<- string)
+}
+
+type SomeChanStruct struct {
+	Done chan struct{}
+}
+
+func SomeChanFunc(a chan (<-chan bool)) chan *SomeChanStruct {
+
+	return nil
+}
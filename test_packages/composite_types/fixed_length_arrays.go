@@ -0,0 +1,20 @@
+package composite_types
+
+import (
+	"crypto/sha256"
+)
+
+const HashLen = 32
+
+type Hash [HashLen]byte
+
+type SomeFixedArrayStruct struct {
+	Checksum [4]byte
+	Grid     [2 * HashLen][3]*float64
+	Digest   [sha256.Size]byte
+}
+
+func SomeFixedArrayFunc(h [HashLen]byte) [8]Hash {
+
+	return [...]Hash{{}, {}, {}, {}, {}, {}, {}, {}}
+}
@@ -0,0 +1,22 @@
+package const_groups
+
+type Colour int
+
+const (
+	ColourUnknown Colour = iota
+	ColourRed
+	ColourGreen
+)
+
+const (
+	_  = iota
+	KB = 1 << (10 * iota)
+	MB
+)
+
+const (
+	A, B = iota, iota * 10
+	C, D
+)
+
+const Single = iota
@@ -0,0 +1,15 @@
+package custom_types
+
+import (
+	c "context"
+)
+
+// OldContext is kept for backwards compatibility
+type OldContext = c.Context
+
+type DefinedContext c.Context
+
+type (
+	IntAlias   = int
+	EmbedAlias = *SingleEmbed
+)
@@ -0,0 +1,15 @@
+package dot_and_blank_imports
+
+import (
+	_ "embed"
+
+	. "github.com/thecodedproject/gopkg/test_packages/dependent_types/nested_pkg"
+)
+
+type LocalType struct{}
+
+type SomeStruct struct {
+	A SomeType
+	B *AnotherType
+	C LocalType
+}
@@ -0,0 +1,48 @@
+package method_sets
+
+type Named struct{}
+
+func (n Named) Name() string {
+	return ""
+}
+
+func (n *Named) SetName(name string) {}
+
+type Counter struct{}
+
+func (c Counter) Count() int {
+	return 0
+}
+
+func (c Counter) Name() string {
+	return ""
+}
+
+func (c *Counter) Inc() {}
+
+type Logger interface {
+	error
+	Log(msg string, args ...any)
+}
+
+type Service struct {
+	Named
+	*Counter
+	Logger
+
+	Close func() error
+}
+
+func (s *Service) Start() error {
+	return nil
+}
+
+type ServiceAlias = Service
+
+type WrappedService Service
+
+type Outer struct {
+	*Service
+
+	Count int
+}
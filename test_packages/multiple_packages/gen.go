@@ -0,0 +1,10 @@
+//go:build ignore
+
+package main
+
+type Shape int
+
+var Default Shape
+
+func main() {
+}
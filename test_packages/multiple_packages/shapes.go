@@ -0,0 +1,7 @@
+package multiple_packages
+
+type Shape struct {
+	Name string
+}
+
+var Default Shape
@@ -0,0 +1,3 @@
+package multiple_packages_test
+
+var Tested bool
@@ -0,0 +1,3 @@
+package hidden
+
+var Skipped int
@@ -0,0 +1,3 @@
+package nested_module
+
+var Skipped int
@@ -0,0 +1,3 @@
+package nested_packages
+
+var Root int
@@ -0,0 +1,3 @@
+package deeper
+
+var Deeper int
@@ -0,0 +1,3 @@
+package subway
+
+var Subway int
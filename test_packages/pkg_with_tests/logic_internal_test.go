@@ -0,0 +1,11 @@
+package pkg_with_tests
+
+import (
+	"testing"
+)
+
+func TestMyCoolLogicInternal(t *testing.T) {
+	if MyCoolLogic(1, 1) != 2 {
+		t.Fail()
+	}
+}
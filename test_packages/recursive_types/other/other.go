@@ -0,0 +1,7 @@
+package other
+
+type Wrapper struct {
+	Inner Inner
+}
+
+type Inner int
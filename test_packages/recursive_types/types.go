@@ -0,0 +1,14 @@
+package recursive_types
+
+import (
+	"github.com/thecodedproject/gopkg/test_packages/recursive_types/other"
+)
+
+type Node struct {
+	Value Value
+	Next  *Node
+}
+
+type Value struct {
+	Other other.Wrapper
+}
@@ -0,0 +1,25 @@
+package type_checked
+
+import (
+	"io"
+)
+
+type Colour int
+
+func (c Colour) String() string {
+	return "colour"
+}
+
+type Point struct {
+	X int
+	Y int
+}
+
+func (p *Point) Move(dx int, dys ...int) {
+}
+
+type Shape struct {
+	Colour Colour
+	Centre *Point
+	Out    io.Writer
+}
@@ -0,0 +1,17 @@
+package type_checked_aliases
+
+type Colour int
+
+type Label = string
+
+type ColourAlias = Colour
+
+type Tagged struct {
+	Label  Label
+	Colour ColourAlias
+	Value  any
+}
+
+type Wrapper struct {
+	Tagged Tagged
+}
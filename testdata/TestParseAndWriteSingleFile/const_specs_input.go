@@ -0,0 +1,23 @@
+package const_specs
+
+// Days of the week
+const (
+	Monday    = 1
+	Tuesday   = 2
+	Wednesday = 3
+)
+
+const (
+	A, B = iota, iota
+	C, D
+)
+
+// Sizes in bytes
+//
+//go:generate echo sizes
+const (
+	// Small is the smallest size
+	Small = 1
+)
+
+var x, y int
@@ -0,0 +1,46 @@
+//go:generate stringer -type=Colour
+
+// Package directives has directives
+package directives
+
+// #include <stdio.h>
+// #include <stdlib.h>
+//
+// #cgo LDFLAGS: -lm
+import "C"
+
+import (
+	_ "embed"
+	"unsafe"
+)
+
+//go:generate echo after imports
+
+//go:embed hello.txt
+var hello string
+
+var (
+	// files holds the static files
+	//
+	//go:embed static/*.txt
+	files string
+
+	other int
+)
+
+// Colour is a colour
+//
+//go:generate echo colour
+type Colour int
+
+//go:noinline
+func add(a, b int) int {
+	return a + b
+}
+
+// free frees the C string
+//
+//export free
+func free(s uintptr) {
+	C.free(unsafe.Pointer(s))
+}
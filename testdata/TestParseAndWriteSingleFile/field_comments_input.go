@@ -0,0 +1,43 @@
+package field_comments
+
+const (
+	// MaxRetries is the number of attempts made
+	MaxRetries = 3 // before giving up
+	Timeout    = 5 // in seconds
+)
+
+var Verbose bool // set from the command line
+
+// Account is returned by the accounts API
+type Account struct {
+	// ID uniquely identifies the account
+	ID int64 `json:"id"` // never zero
+
+	// Name is the display name of the account
+	//
+	// It may be changed by the account owner
+	Name string `json:"name"`
+
+	Balance int64 // in cents
+}
+
+type AccountStore interface {
+	// Get returns the account with the given id
+	Get(id int64) (Account, error)
+
+	Delete(id int64) error // removes the account permanently
+}
+
+type Point struct {
+	// X and Y are the coordinates of the point
+	X, Y int // in pixels
+}
+
+// Admin is an account with extra permissions
+type Admin struct {
+	// Account is the account of the admin
+	Account
+	AccountStore // used to modify accounts
+
+	Level int
+}
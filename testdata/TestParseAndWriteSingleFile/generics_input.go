@@ -0,0 +1,31 @@
+package generics
+
+type Number interface {
+	~int | ~int64 | float64
+}
+
+type Set[K comparable, V Number] struct {
+	Items map[K]V
+}
+
+type List[T interface{}] []T
+
+func (s *Set[K, V]) Add(k K, v V) {
+	s.Items[k] = v
+}
+
+func (s Set[_, V]) Sum() V {
+	var sum V
+	for _, v := range s.Items {
+		sum += v
+	}
+	return sum
+}
+
+func MapKeys[S ~[]E, E comparable](s S) *Set[E, int] {
+	return nil
+}
+
+func ListOfPointers() List[*int] {
+	return nil
+}
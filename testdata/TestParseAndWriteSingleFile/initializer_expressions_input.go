@@ -0,0 +1,43 @@
+package initializer_expressions
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	KiB = 1 << 10
+	MiB = KiB << 10
+)
+
+const Timeout = time.Second * 5
+
+const Greeting string = "hello" + ", " + "world"
+
+var Upper = strings.ToUpper(Greeting)
+
+var Lookup = map[string]int{
+	"a": 1,
+	"b": 2,
+}
+
+var Names = []string{"x", "y", "z"}
+
+var Point = struct {
+	X int
+	Y int
+}{X: 1, Y: 2}
+
+var Double = func(i int) int {
+	return i * 2
+}
+
+var Negative = -KiB
+
+var Port, PortErr = strconv.Atoi("8080")
+
+var (
+	Width, WidthErr   = strconv.Atoi("80")
+	Height, HeightErr = strconv.ParseInt("24", 10, 64)
+)
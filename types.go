@@ -2,6 +2,10 @@ package gopkg
 
 import (
 	"errors"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"strings"
 )
 
 type FileContents struct {
@@ -9,8 +13,23 @@ type FileContents struct {
 
 	PackageName string
 
+	// PackageImportPath is the import path of the package
+	//
+	// When parsed, only the main package of a directory (and its external
+	// test package) have the directory's import path. The files of any other
+	// package in the directory, such as a `//go:build ignore` generator in
+	// `package main`, have the directory's import path followed by
+	// `#<package name>` (e.g. `some/import/path#main`), which cannot be
+	// imported but keeps the package separate.
 	PackageImportPath string
 
+	// IsTest is true for `_test.go` files
+	//
+	// Test files may either be within the package itself or within its
+	// external test package, in which case both PackageName and
+	// PackageImportPath are suffixed with `_test`.
+	IsTest bool
+
 	Imports []ImportAndAlias
 
 	Consts    []DeclVar
@@ -19,6 +38,34 @@ type FileContents struct {
 	Functions []DeclFunc
 
 	DocString string
+
+	// GeneratedBy is the name of the generator which produces this file.
+	//
+	// If set, the file is written with the standard generated code header
+	// (`// Code generated by <GeneratedBy>. DO NOT EDIT.`), which is recognised
+	// by `go vet` and linters, and Generate will refuse to overwrite an
+	// existing file which does not have a generated code header.
+	GeneratedBy string
+
+	// BuildConstraint is the expression of the file's `//go:build` line
+	// (e.g. `linux && amd64`), which is written above the package clause.
+	//
+	// When parsed, this is read from a `//go:build` line (or from legacy
+	// `// +build` lines if there is no `//go:build` line). Constraints
+	// implied by the filename (e.g. `_linux.go`) are not included.
+	BuildConstraint string
+
+	// Directives are the directives in the file which do not belong to a
+	// declaration (e.g. `//go:generate stringer -type=Colour`), which are
+	// written above the package clause.
+	Directives []string
+
+	// CgoPreamble is the comment immediately preceding `import "C"` (e.g.
+	// `// #include <stdio.h>`).
+	//
+	// The "C" import is always written in its own import declaration,
+	// preceded by the preamble, as required by cgo.
+	CgoPreamble string
 }
 
 type ImportAndAlias struct {
@@ -84,6 +131,103 @@ func (t TypeArray) RequiredImports() map[string]bool {
 	return t.ValueType.RequiredImports()
 }
 
+// TypeFixedArray is a fixed length array type (e.g. `[32]byte`)
+type TypeFixedArray struct {
+	// Len is the length expression of the array as written in the source; e.g.
+	// a literal (`32`), a named constant (`MyLen`) or an ellipsis (`...`, only
+	// valid in composite literals)
+	Len string
+
+	// LenImports is the import path of each package used in Len, by the
+	// package name it is qualified with in Len
+	//
+	// e.g. for `[sha256.Size]byte`, LenImports will be
+	// `{"sha256": "crypto/sha256"}`
+	//
+	// When written, these qualifiers are replaced by the alias of the import
+	// path (if it has one).
+	LenImports map[string]string
+
+	ValueType Type
+}
+
+func (t TypeFixedArray) DefaultInit(importAliases map[string]string) (string, error) {
+
+	fullType, err := t.FullType(importAliases)
+	if err != nil {
+		return "", err
+	}
+
+	return fullType + "{}", nil
+}
+
+func (t TypeFixedArray) FullType(importAliases map[string]string) (string, error) {
+
+	if t.Len == "" {
+		return "", errors.New("fixed array length cannot be empty")
+	}
+
+	valueFullType, err := t.ValueType.FullType(importAliases)
+	if err != nil {
+		return "", err
+	}
+
+	length, err := t.aliasedLen(importAliases)
+	if err != nil {
+		return "", err
+	}
+
+	return "[" + length + "]" + valueFullType, nil
+}
+
+func (t TypeFixedArray) RequiredImports() map[string]bool {
+
+	ret := make(map[string]bool)
+	for _, importPath := range t.LenImports {
+		ret[importPath] = true
+	}
+	return union(ret, t.ValueType.RequiredImports())
+}
+
+// aliasedLen returns Len with each package qualifier in LenImports replaced by
+// the alias of its import path
+func (t TypeFixedArray) aliasedLen(importAliases map[string]string) (string, error) {
+
+	if len(t.LenImports) == 0 {
+		return t.Len, nil
+	}
+
+	lenExpr, err := parser.ParseExpr(t.Len)
+	if err != nil {
+		return "", errors.New("invalid fixed array length '" + t.Len + "'")
+	}
+
+	ast.Inspect(lenExpr, func(n ast.Node) bool {
+
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		x, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+
+		importPath, ok := t.LenImports[x.Name]
+		if !ok {
+			return true
+		}
+
+		if alias, hasAlias := importAliases[importPath]; hasAlias {
+			x.Name = alias
+		}
+		return false
+	})
+
+	return types.ExprString(lenExpr), nil
+}
+
 type TypeBool struct{}
 
 func (t TypeBool) DefaultInit(importAliases map[string]string) (string, error) {
@@ -112,6 +256,103 @@ func (t TypeByte) RequiredImports() map[string]bool {
 	return nil
 }
 
+// ChanDir is the direction of a channel type
+type ChanDir int
+
+const (
+	// ChanDirBoth is a bidirectional channel (i.e. `chan T`)
+	ChanDirBoth ChanDir = iota
+	// ChanDirSend is a send-only channel (i.e. `chan<- T`)
+	ChanDirSend
+	// ChanDirRecv is a receive-only channel (i.e. `<-chan T`)
+	ChanDirRecv
+)
+
+type TypeChan struct {
+	Dir       ChanDir
+	ValueType Type
+}
+
+func (t TypeChan) DefaultInit(importAliases map[string]string) (string, error) {
+	return "nil", nil
+}
+
+func (t TypeChan) FullType(importAliases map[string]string) (string, error) {
+
+	valueFullType, err := t.ValueType.FullType(importAliases)
+	if err != nil {
+		return "", err
+	}
+
+	// A receive-only channel value type must be wrapped in parentheses unless
+	// this channel is also receive-only, otherwise the `<-` binds to the
+	// leftmost `chan` (e.g. `chan <-chan int` is `chan<- (chan int)`)
+	if valueChan, ok := t.ValueType.(TypeChan); ok {
+		if valueChan.Dir == ChanDirRecv && t.Dir != ChanDirRecv {
+			valueFullType = "(" + valueFullType + ")"
+		}
+	}
+
+	switch t.Dir {
+	case ChanDirBoth:
+		return "chan " + valueFullType, nil
+	case ChanDirSend:
+		return "chan<- " + valueFullType, nil
+	case ChanDirRecv:
+		return "<-chan " + valueFullType, nil
+	default:
+		return "", errors.New("unknown channel direction")
+	}
+}
+
+func (t TypeChan) RequiredImports() map[string]bool {
+	return t.ValueType.RequiredImports()
+}
+
+// TypeComparable is the predeclared `comparable` interface, which may only be
+// used as a type param constraint
+type TypeComparable struct{}
+
+func (t TypeComparable) DefaultInit(importAliases map[string]string) (string, error) {
+	return "", errors.New("no default init for comparable")
+}
+
+func (t TypeComparable) FullType(importAliases map[string]string) (string, error) {
+	return "comparable", nil
+}
+
+func (t TypeComparable) RequiredImports() map[string]bool {
+	return nil
+}
+
+type TypeComplex64 struct{}
+
+func (t TypeComplex64) DefaultInit(importAliases map[string]string) (string, error) {
+	return "0", nil
+}
+
+func (t TypeComplex64) FullType(importAliases map[string]string) (string, error) {
+	return "complex64", nil
+}
+
+func (t TypeComplex64) RequiredImports() map[string]bool {
+	return nil
+}
+
+type TypeComplex128 struct{}
+
+func (t TypeComplex128) DefaultInit(importAliases map[string]string) (string, error) {
+	return "0", nil
+}
+
+func (t TypeComplex128) FullType(importAliases map[string]string) (string, error) {
+	return "complex128", nil
+}
+
+func (t TypeComplex128) RequiredImports() map[string]bool {
+	return nil
+}
+
 type TypeError struct{}
 
 func (t TypeError) DefaultInit(importAliases map[string]string) (string, error) {
@@ -197,7 +438,7 @@ func (t TypeInterface) FullType(importAliases map[string]string) (string, error)
 		ret += "\n"
 	}
 
-	for _, f := range t.Funcs {
+	for i, f := range t.Funcs {
 
 		argsAndRets, err := funcArgsAndRetArgs(
 			f.Args,
@@ -210,7 +451,16 @@ func (t TypeInterface) FullType(importAliases map[string]string) (string, error)
 			return "", err
 		}
 
-		ret += "\t" + f.Name + argsAndRets + "\n"
+		// vanity space methods if there is a docstring
+		if f.DocString != "" && i != 0 {
+			ret += "\n"
+		}
+		ret += indentedDocString(f.DocString)
+		ret += "\t" + f.Name + argsAndRets
+		if f.Comment != "" {
+			ret += " " + f.Comment
+		}
+		ret += "\n"
 	}
 	ret += "}"
 
@@ -228,6 +478,34 @@ func (t TypeInterface) RequiredImports() map[string]bool {
 	return ret
 }
 
+type TypeInt8 struct{}
+
+func (t TypeInt8) DefaultInit(importAliases map[string]string) (string, error) {
+	return "0", nil
+}
+
+func (t TypeInt8) FullType(importAliases map[string]string) (string, error) {
+	return "int8", nil
+}
+
+func (t TypeInt8) RequiredImports() map[string]bool {
+	return nil
+}
+
+type TypeInt16 struct{}
+
+func (t TypeInt16) DefaultInit(importAliases map[string]string) (string, error) {
+	return "0", nil
+}
+
+func (t TypeInt16) FullType(importAliases map[string]string) (string, error) {
+	return "int16", nil
+}
+
+func (t TypeInt16) RequiredImports() map[string]bool {
+	return nil
+}
+
 type TypeInt32 struct{}
 
 func (t TypeInt32) DefaultInit(importAliases map[string]string) (string, error) {
@@ -256,6 +534,20 @@ func (t TypeInt64) RequiredImports() map[string]bool {
 	return nil
 }
 
+type TypeRune struct{}
+
+func (t TypeRune) DefaultInit(importAliases map[string]string) (string, error) {
+	return "0", nil
+}
+
+func (t TypeRune) FullType(importAliases map[string]string) (string, error) {
+	return "rune", nil
+}
+
+func (t TypeRune) RequiredImports() map[string]bool {
+	return nil
+}
+
 type TypeString struct{}
 
 func (t TypeString) DefaultInit(importAliases map[string]string) (string, error) {
@@ -271,10 +563,21 @@ func (t TypeString) RequiredImports() map[string]bool {
 }
 
 type TypeStruct struct {
-	Embeds []Type
+	Embeds []StructEmbed
 	Fields []DeclVar
 }
 
+// StructEmbed is an embedded field of a struct
+type StructEmbed struct {
+	Type Type
+
+	DocString string
+
+	// Comment is the trailing line comment of the embedded field
+	// (e.g. `// for logging`)
+	Comment string
+}
+
 func (t TypeStruct) DefaultInit(importAliases map[string]string) (string, error) {
 	return "{}", nil
 }
@@ -287,27 +590,62 @@ func (t TypeStruct) FullType(importAliases map[string]string) (string, error) {
 		if i == 0 {
 			ret += "\n"
 		}
-		eFullType, err := e.FullType(importAliases)
+		eFullType, err := e.Type.FullType(importAliases)
 		if err != nil {
 			return "", err
 		}
-		ret += "\t" + eFullType + "\n"
+		// vanity space embeds if there is a docstring
+		if e.DocString != "" && i != 0 {
+			ret += "\n"
+		}
+		ret += indentedDocString(e.DocString)
+		ret += "\t" + eFullType
+
+		if e.Comment != "" {
+			ret += " " + e.Comment
+		}
+
+		ret += "\n"
 	}
 
 	for i, f := range t.Fields {
 		if i == 0 {
 			ret += "\n"
 		}
+
+		// Fields sharing a spec are written on the line of the field they
+		// follow (e.g. `A, B int`)
+		if f.SharesSpec && i != 0 {
+			continue
+		}
+
+		names := []string{f.Name}
+		for _, next := range t.Fields[i+1:] {
+			if !next.SharesSpec {
+				break
+			}
+			names = append(names, next.Name)
+		}
+
 		fieldFullType, err := f.FullType(importAliases)
 		if err != nil {
 			return "", err
 		}
-		ret += "\t" + f.Name + " " + fieldFullType
+		// vanity space fields if there is a docstring
+		if f.DocString != "" && i != 0 {
+			ret += "\n"
+		}
+		ret += indentedDocString(f.DocString)
+		ret += "\t" + strings.Join(names, ", ") + " " + fieldFullType
 
 		if f.StructTag != "" {
 			ret += " `" + string(f.StructTag) + "`"
 		}
 
+		if f.Comment != "" {
+			ret += " " + f.Comment
+		}
+
 		ret += "\n"
 	}
 
@@ -319,7 +657,7 @@ func (t TypeStruct) FullType(importAliases map[string]string) (string, error) {
 func (t TypeStruct) RequiredImports() map[string]bool {
 	ret := make(map[string]bool)
 	for _, e := range t.Embeds {
-		ret = union(ret, e.RequiredImports())
+		ret = union(ret, e.Type.RequiredImports())
 	}
 	for _, f := range t.Fields {
 		ret = union(ret, f.RequiredImports())
@@ -327,11 +665,101 @@ func (t TypeStruct) RequiredImports() map[string]bool {
 	return ret
 }
 
+type TypeUint struct{}
+
+func (t TypeUint) DefaultInit(importAliases map[string]string) (string, error) {
+	return "0", nil
+}
+
+func (t TypeUint) FullType(importAliases map[string]string) (string, error) {
+	return "uint", nil
+}
+
+func (t TypeUint) RequiredImports() map[string]bool {
+	return nil
+}
+
+type TypeUint8 struct{}
+
+func (t TypeUint8) DefaultInit(importAliases map[string]string) (string, error) {
+	return "0", nil
+}
+
+func (t TypeUint8) FullType(importAliases map[string]string) (string, error) {
+	return "uint8", nil
+}
+
+func (t TypeUint8) RequiredImports() map[string]bool {
+	return nil
+}
+
+type TypeUint16 struct{}
+
+func (t TypeUint16) DefaultInit(importAliases map[string]string) (string, error) {
+	return "0", nil
+}
+
+func (t TypeUint16) FullType(importAliases map[string]string) (string, error) {
+	return "uint16", nil
+}
+
+func (t TypeUint16) RequiredImports() map[string]bool {
+	return nil
+}
+
+type TypeUint32 struct{}
+
+func (t TypeUint32) DefaultInit(importAliases map[string]string) (string, error) {
+	return "0", nil
+}
+
+func (t TypeUint32) FullType(importAliases map[string]string) (string, error) {
+	return "uint32", nil
+}
+
+func (t TypeUint32) RequiredImports() map[string]bool {
+	return nil
+}
+
+type TypeUint64 struct{}
+
+func (t TypeUint64) DefaultInit(importAliases map[string]string) (string, error) {
+	return "0", nil
+}
+
+func (t TypeUint64) FullType(importAliases map[string]string) (string, error) {
+	return "uint64", nil
+}
+
+func (t TypeUint64) RequiredImports() map[string]bool {
+	return nil
+}
+
+type TypeUintptr struct{}
+
+func (t TypeUintptr) DefaultInit(importAliases map[string]string) (string, error) {
+	return "0", nil
+}
+
+func (t TypeUintptr) FullType(importAliases map[string]string) (string, error) {
+	return "uintptr", nil
+}
+
+func (t TypeUintptr) RequiredImports() map[string]bool {
+	return nil
+}
+
 // TODO rename to something more approriate - maybe TypeNamed (or TypeAlias)
 type TypeNamed struct {
 	Name      string
 	Import    string
 	ValueType Type
+
+	// Methods are the methods declared on the named type, with either value
+	// or pointer receivers.
+	//
+	// This is only set when parsing with ParseTypeChecked.
+	Methods []DeclFunc
 }
 
 func (t TypeNamed) DefaultInit(importAliases map[string]string) (string, error) {
@@ -372,6 +800,72 @@ func (t TypeNamed) RequiredImports() map[string]bool {
 	return nil
 }
 
+// TypeGeneric is an instantiation of a generic named type with a list of
+// type arguments
+//
+// e.g. `Set[K, V]` or `some_pkg.List[int]`
+type TypeGeneric struct {
+	Name      string
+	Import    string
+	TypeArgs  []Type
+	ValueType Type
+}
+
+func (t TypeGeneric) DefaultInit(importAliases map[string]string) (string, error) {
+
+	if t.ValueType != nil {
+
+		switch t.ValueType.(type) {
+		case TypeStruct:
+
+			structFullType, err := t.FullType(importAliases)
+			if err != nil {
+				return "", err
+			}
+
+			return structFullType + "{}", nil
+		default:
+			return t.ValueType.DefaultInit(importAliases)
+		}
+	}
+
+	return "", errors.New("cannot deduce default init for generic type with no value type")
+}
+
+func (t TypeGeneric) FullType(importAliases map[string]string) (string, error) {
+
+	ret := t.Name
+	if alias, hasAlias := importAliases[t.Import]; hasAlias {
+		ret = alias + "." + t.Name
+	}
+
+	ret += "["
+	for i, arg := range t.TypeArgs {
+		if i > 0 {
+			ret += ", "
+		}
+		argFullType, err := arg.FullType(importAliases)
+		if err != nil {
+			return "", err
+		}
+		ret += argFullType
+	}
+	ret += "]"
+
+	return ret, nil
+}
+
+func (t TypeGeneric) RequiredImports() map[string]bool {
+	ret := make(map[string]bool)
+	if t.Import != "" {
+		ret[t.Import] = true
+	}
+	for _, arg := range t.TypeArgs {
+		ret = union(ret, arg.RequiredImports())
+	}
+	return ret
+}
+
 type TypeMap struct {
 	KeyType   Type
 	ValueType Type
@@ -425,6 +919,54 @@ func (t TypePointer) RequiredImports() map[string]bool {
 	return t.ValueType.RequiredImports()
 }
 
+// TypeUnion is a union of types, as used in type parameter constraints
+//
+// e.g. `~int | ~string | float64`
+//
+// A single approximation element (e.g. `~int`) is a TypeUnion with only one
+// term.
+type TypeUnion struct {
+	Terms []TypeUnionTerm
+}
+
+type TypeUnionTerm struct {
+	Type Type
+
+	// Tilde is true if the term is an approximation element (i.e. `~T`)
+	Tilde bool
+}
+
+func (t TypeUnion) DefaultInit(importAliases map[string]string) (string, error) {
+	return "", errors.New("no default init for union type")
+}
+
+func (t TypeUnion) FullType(importAliases map[string]string) (string, error) {
+
+	var ret string
+	for i, term := range t.Terms {
+		if i > 0 {
+			ret += " | "
+		}
+		if term.Tilde {
+			ret += "~"
+		}
+		termFullType, err := term.Type.FullType(importAliases)
+		if err != nil {
+			return "", err
+		}
+		ret += termFullType
+	}
+	return ret, nil
+}
+
+func (t TypeUnion) RequiredImports() map[string]bool {
+	ret := make(map[string]bool)
+	for _, term := range t.Terms {
+		ret = union(ret, term.Type.RequiredImports())
+	}
+	return ret
+}
+
 type TypeUnnamedLiteral struct{}
 
 func (t TypeUnnamedLiteral) DefaultInit(importAliases map[string]string) (string, error) {
@@ -480,3 +1022,20 @@ func (t TypeFunc) RequiredImports() map[string]bool {
 	}
 	return ret
 }
+
+// indentedDocString returns `docString` with each of its lines indented by a
+// single tab, for writing above a struct field or interface method
+//
+// Returns an empty string if `docString` is empty.
+func indentedDocString(docString string) string {
+
+	if docString == "" {
+		return ""
+	}
+
+	var ret string
+	for _, line := range strings.Split(docString, "\n") {
+		ret += "\t" + strings.TrimSpace(line) + "\n"
+	}
+	return ret
+}
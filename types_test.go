@@ -46,6 +46,20 @@ func TestTypeDefaultInit(t *testing.T) {
 			Def:      gopkg.TypeFunc{},
 			Expected: "nil",
 		},
+		{
+			Def: gopkg.TypeFixedArray{
+				Len:       "32",
+				ValueType: gopkg.TypeByte{},
+			},
+			Expected: "[32]byte{}",
+		},
+		{
+			Def: gopkg.TypeChan{
+				Dir:       gopkg.ChanDirRecv,
+				ValueType: gopkg.TypeString{},
+			},
+			Expected: "nil",
+		},
 		{
 			Def:      gopkg.TypeInt{},
 			Expected: "0",
@@ -62,6 +76,54 @@ func TestTypeDefaultInit(t *testing.T) {
 			Def:      gopkg.TypeString{},
 			Expected: "\"\"",
 		},
+		{
+			Def:      gopkg.TypeInt8{},
+			Expected: "0",
+		},
+		{
+			Def:      gopkg.TypeInt16{},
+			Expected: "0",
+		},
+		{
+			Def:      gopkg.TypeUint{},
+			Expected: "0",
+		},
+		{
+			Def:      gopkg.TypeUint8{},
+			Expected: "0",
+		},
+		{
+			Def:      gopkg.TypeUint16{},
+			Expected: "0",
+		},
+		{
+			Def:      gopkg.TypeUint32{},
+			Expected: "0",
+		},
+		{
+			Def:      gopkg.TypeUint64{},
+			Expected: "0",
+		},
+		{
+			Def:      gopkg.TypeUintptr{},
+			Expected: "0",
+		},
+		{
+			Def:      gopkg.TypeRune{},
+			Expected: "0",
+		},
+		{
+			Def:      gopkg.TypeComplex64{},
+			Expected: "0",
+		},
+		{
+			Def:      gopkg.TypeComplex128{},
+			Expected: "0",
+		},
+		{
+			Def:         gopkg.TypeComparable{},
+			ExpectedErr: errors.New("no default init for comparable"),
+		},
 		{
 			Def:      gopkg.TypeInterface{},
 			Expected: "nil",
@@ -111,6 +173,24 @@ func TestTypeDefaultInit(t *testing.T) {
 			},
 			ExpectedErr: errors.New("cannot deduce default init for named type with no value type"),
 		},
+		{
+			Def: gopkg.TypeGeneric{
+				Name:   "MyType",
+				Import: "my/import/path",
+				TypeArgs: []gopkg.Type{
+					gopkg.TypeInt{},
+				},
+			},
+			ExpectedErr: errors.New("cannot deduce default init for generic type with no value type"),
+		},
+		{
+			Def: gopkg.TypeUnion{
+				Terms: []gopkg.TypeUnionTerm{
+					{Type: gopkg.TypeInt{}, Tilde: true},
+				},
+			},
+			ExpectedErr: errors.New("no default init for union type"),
+		},
 		{
 			Def:         gopkg.TypeUnnamedLiteral{},
 			ExpectedErr: errors.New("no default init for unnamed literal"),
@@ -202,6 +282,54 @@ func TestTypeFullType(t *testing.T) {
 			Def:      gopkg.TypeString{},
 			Expected: "string",
 		},
+		{
+			Def:      gopkg.TypeInt8{},
+			Expected: "int8",
+		},
+		{
+			Def:      gopkg.TypeInt16{},
+			Expected: "int16",
+		},
+		{
+			Def:      gopkg.TypeUint{},
+			Expected: "uint",
+		},
+		{
+			Def:      gopkg.TypeUint8{},
+			Expected: "uint8",
+		},
+		{
+			Def:      gopkg.TypeUint16{},
+			Expected: "uint16",
+		},
+		{
+			Def:      gopkg.TypeUint32{},
+			Expected: "uint32",
+		},
+		{
+			Def:      gopkg.TypeUint64{},
+			Expected: "uint64",
+		},
+		{
+			Def:      gopkg.TypeUintptr{},
+			Expected: "uintptr",
+		},
+		{
+			Def:      gopkg.TypeRune{},
+			Expected: "rune",
+		},
+		{
+			Def:      gopkg.TypeComplex64{},
+			Expected: "complex64",
+		},
+		{
+			Def:      gopkg.TypeComplex128{},
+			Expected: "complex128",
+		},
+		{
+			Def:      gopkg.TypeComparable{},
+			Expected: "comparable",
+		},
 		{
 			Def: gopkg.TypeArray{
 				ValueType: gopkg.TypeNamed{
@@ -243,6 +371,129 @@ func TestTypeFullType(t *testing.T) {
 			},
 			Expected: "map[path_alias.MyType]*[]other_alias.MyOtherType",
 		},
+		{
+			Def: gopkg.TypeGeneric{
+				Name:   "Set",
+				Import: "my/import/path",
+				TypeArgs: []gopkg.Type{
+					gopkg.TypeString{},
+					gopkg.TypePointer{
+						ValueType: gopkg.TypeNamed{
+							Name:   "MyOtherType",
+							Import: "other/import",
+						},
+					},
+				},
+			},
+			ImportAliases: map[string]string{
+				"my/import/path": "path_alias",
+				"other/import":   "other_alias",
+			},
+			Expected: "path_alias.Set[string, *other_alias.MyOtherType]",
+		},
+		{
+			Def: gopkg.TypeUnion{
+				Terms: []gopkg.TypeUnionTerm{
+					{Type: gopkg.TypeInt{}, Tilde: true},
+					{Type: gopkg.TypeFloat64{}},
+					{
+						Type: gopkg.TypeNamed{
+							Name:   "MyType",
+							Import: "my/import/path",
+						},
+						Tilde: true,
+					},
+				},
+			},
+			ImportAliases: map[string]string{
+				"my/import/path": "path_alias",
+			},
+			Expected: "~int | float64 | ~path_alias.MyType",
+		},
+		{
+			Def: gopkg.TypeChan{
+				ValueType: gopkg.TypeNamed{
+					Name:   "SomeType",
+					Import: "some/import",
+				},
+			},
+			ImportAliases: map[string]string{
+				"some/import": "some_alias",
+			},
+			Expected: "chan some_alias.SomeType",
+		},
+		{
+			Def: gopkg.TypeChan{
+				Dir:       gopkg.ChanDirSend,
+				ValueType: gopkg.TypeInt{},
+			},
+			Expected: "chan<- int",
+		},
+		{
+			Def: gopkg.TypeChan{
+				Dir: gopkg.ChanDirRecv,
+				ValueType: gopkg.TypeChan{
+					Dir:       gopkg.ChanDirRecv,
+					ValueType: gopkg.TypeInt{},
+				},
+			},
+			Expected: "<-chan <-chan int",
+		},
+		{
+			Def: gopkg.TypeChan{
+				ValueType: gopkg.TypeChan{
+					Dir:       gopkg.ChanDirRecv,
+					ValueType: gopkg.TypeInt{},
+				},
+			},
+			Expected: "chan (<-chan int)",
+		},
+		{
+			Def: gopkg.TypeChan{
+				Dir: gopkg.ChanDirSend,
+				ValueType: gopkg.TypeChan{
+					Dir:       gopkg.ChanDirSend,
+					ValueType: gopkg.TypeInt{},
+				},
+			},
+			Expected: "chan<- chan<- int",
+		},
+		{
+			Def: gopkg.TypeFixedArray{
+				Len: "32",
+				ValueType: gopkg.TypeNamed{
+					Name:   "SomeType",
+					Import: "some/import",
+				},
+			},
+			ImportAliases: map[string]string{
+				"some/import": "some_alias",
+			},
+			Expected: "[32]some_alias.SomeType",
+		},
+		{
+			Def: gopkg.TypeFixedArray{
+				Len: "...",
+				ValueType: gopkg.TypeFixedArray{
+					Len:       "MyLen",
+					ValueType: gopkg.TypeByte{},
+				},
+			},
+			Expected: "[...][MyLen]byte",
+		},
+		{
+			Def: gopkg.TypeFixedArray{
+				Len: "sha256.Size * 2",
+				LenImports: map[string]string{
+					"sha256": "crypto/sha256",
+				},
+				ValueType: gopkg.TypeByte{},
+			},
+			ImportAliases: map[string]string{
+				"crypto/sha256": "crypto_sha256",
+			},
+			Expected: "[crypto_sha256.Size * 2]byte",
+		},
 		{
 			Def:      gopkg.TypeUnnamedLiteral{},
 			Expected: "",
@@ -495,16 +746,20 @@ func TestTypeStructFullType(t *testing.T) {
 		{
 			Name: "struct with embedded types only",
 			Def: gopkg.TypeStruct{
-				Embeds: []gopkg.Type{
-					gopkg.TypeNamed{
-						Name:   "MyType",
-						Import: "github.com/myrepo",
+				Embeds: []gopkg.StructEmbed{
+					{
+						Type: gopkg.TypeNamed{
+							Name:   "MyType",
+							Import: "github.com/myrepo",
+						},
 					},
-					gopkg.TypeNamed{
-						Name:   "MyTypeTwo",
-						Import: "github.com/myotherrepo",
+					{
+						Type: gopkg.TypeNamed{
+							Name:   "MyTypeTwo",
+							Import: "github.com/myotherrepo",
+						},
 					},
-					gopkg.TypeError{},
+					{Type: gopkg.TypeError{}},
 				},
 			},
 			ImportAliases: map[string]string{
@@ -551,9 +806,9 @@ func TestTypeStructFullType(t *testing.T) {
 		{
 			Name: "struct with fields and embedded types",
 			Def: gopkg.TypeStruct{
-				Embeds: []gopkg.Type{
-					gopkg.TypeInt32{},
-					gopkg.TypeError{},
+				Embeds: []gopkg.StructEmbed{
+					{Type: gopkg.TypeInt32{}},
+					{Type: gopkg.TypeError{}},
 				},
 				Fields: []gopkg.DeclVar{
 					{
@@ -584,6 +839,91 @@ func TestTypeStructFullType(t *testing.T) {
 
 	MyVal myrepo.SomeImportedType
 	MyOtherVal *myotherrepo.SomeOtherImportedType
+}`,
+		},
+		{
+			Name: "struct with doc strings and comments on fields",
+			Def: gopkg.TypeStruct{
+				Fields: []gopkg.DeclVar{
+					{
+						Name:      "ID",
+						Type:      gopkg.TypeInt64{},
+						StructTag: `json:"id"`,
+						DocString: "// ID is the identifier",
+						Comment:   "// never zero",
+					},
+					{
+						Name:      "Name",
+						Type:      gopkg.TypeString{},
+						DocString: "// Name has a docstring\n\t// on multiple lines",
+					},
+					{
+						Name:    "Balance",
+						Type:    gopkg.TypeInt64{},
+						Comment: "// in cents",
+					},
+				},
+			},
+			Expected: `struct {
+	// ID is the identifier
+	ID int64 ` + "`json:\"id\"`" + ` // never zero
+
+	// Name has a docstring
+	// on multiple lines
+	Name string
+	Balance int64 // in cents
+}`,
+		},
+		{
+			Name: "struct with fields sharing a spec",
+			Def: gopkg.TypeStruct{
+				Fields: []gopkg.DeclVar{
+					{
+						Name:    "X",
+						Type:    gopkg.TypeInt{},
+						Comment: "// in pixels",
+					},
+					{
+						Name:       "Y",
+						Type:       gopkg.TypeInt{},
+						SharesSpec: true,
+					},
+					{
+						Name: "Label",
+						Type: gopkg.TypeString{},
+					},
+				},
+			},
+			Expected: `struct {
+	X, Y int // in pixels
+	Label string
+}`,
+		},
+		{
+			Name: "struct with doc strings and comments on embedded fields",
+			Def: gopkg.TypeStruct{
+				Embeds: []gopkg.StructEmbed{
+					{
+						Type:      gopkg.TypeNamed{Name: "First"},
+						DocString: "// First has a docstring",
+					},
+					{
+						Type:    gopkg.TypeNamed{Name: "Second"},
+						Comment: "// and second has a comment",
+					},
+					{
+						Type:      gopkg.TypeNamed{Name: "Third"},
+						DocString: "// Third has a docstring",
+					},
+				},
+			},
+			Expected: `struct {
+	// First has a docstring
+	First
+	Second // and second has a comment
+
+	// Third has a docstring
+	Third
 }`,
 		},
 	}
@@ -812,6 +1152,33 @@ func TestTypeInterfaceFullType(t *testing.T) {
 			Expected: `interface {
 	One() (val alias_b.BStruct, err error)
 	Two() (secondVal alias_c.CStruct)
+}`,
+		},
+		{
+			Name: "functions with doc strings and comments",
+			Def: gopkg.TypeInterface{
+				Funcs: []gopkg.DeclFunc{
+					{
+						Name:      "One",
+						DocString: "// One has a docstring",
+					},
+					{
+						Name:    "Two",
+						Comment: "// and two has a comment",
+					},
+					{
+						Name:      "Three",
+						DocString: "// Three has a docstring",
+					},
+				},
+			},
+			Expected: `interface {
+	// One has a docstring
+	One()
+	Two() // and two has a comment
+
+	// Three has a docstring
+	Three()
 }`,
 		},
 	}
@@ -986,6 +1353,19 @@ func TestTypeRequiredImports_SimpleTypes(t *testing.T) {
 		gopkg.TypeInt32{},
 		gopkg.TypeInt64{},
 		gopkg.TypeString{},
+		gopkg.TypeInt8{},
+		gopkg.TypeInt16{},
+		gopkg.TypeUint{},
+		gopkg.TypeUint8{},
+		gopkg.TypeUint16{},
+		gopkg.TypeUint32{},
+		gopkg.TypeUint64{},
+		gopkg.TypeUintptr{},
+		gopkg.TypeRune{},
+		gopkg.TypeComplex64{},
+		gopkg.TypeComplex128{},
+		gopkg.TypeAny{},
+		gopkg.TypeComparable{},
 	}
 
 	for _, simpleType := range simpleTypes {
@@ -1034,6 +1414,34 @@ func TestTypeRequiredImports_CompositeTypes(t *testing.T) {
 				"array/other/import": true,
 			},
 		},
+		{
+			Name: "fixed array of named type",
+			Def: gopkg.TypeFixedArray{
+				Len: "4",
+				ValueType: gopkg.TypeNamed{
+					Import: "fixed/array/import",
+				},
+			},
+			Expected: map[string]bool{
+				"fixed/array/import": true,
+			},
+		},
+		{
+			Name: "fixed array with imported length",
+			Def: gopkg.TypeFixedArray{
+				Len: "sha256.Size",
+				LenImports: map[string]string{
+					"sha256": "crypto/sha256",
+				},
+				ValueType: gopkg.TypeNamed{
+					Import: "fixed/array/import",
+				},
+			},
+			Expected: map[string]bool{
+				"crypto/sha256":      true,
+				"fixed/array/import": true,
+			},
+		},
 		{
 			Name: "pointer of simple type",
 			Def: gopkg.TypePointer{
@@ -1058,6 +1466,25 @@ func TestTypeRequiredImports_CompositeTypes(t *testing.T) {
 				"pointer/other/import": true,
 			},
 		},
+		{
+			Name: "chan of simple type",
+			Def: gopkg.TypeChan{
+				ValueType: gopkg.TypeString{},
+			},
+			Expected: map[string]bool(nil),
+		},
+		{
+			Name: "chan of named type",
+			Def: gopkg.TypeChan{
+				Dir: gopkg.ChanDirSend,
+				ValueType: gopkg.TypeNamed{
+					Import: "chan/an/import",
+				},
+			},
+			Expected: map[string]bool{
+				"chan/an/import": true,
+			},
+		},
 		{
 			Name: "map with simple types",
 			Def: gopkg.TypeMap{
@@ -1127,13 +1554,17 @@ func TestTypeRequiredImports_CompositeTypes(t *testing.T) {
 		{
 			Name: "struct with named embedded types",
 			Def: gopkg.TypeStruct{
-				Embeds: []gopkg.Type{
-					gopkg.TypeNamed{
-						Import: "import/a",
+				Embeds: []gopkg.StructEmbed{
+					{
+						Type: gopkg.TypeNamed{
+							Import: "import/a",
+						},
 					},
-					gopkg.TypeString{},
-					gopkg.TypeNamed{
-						Import: "import/b",
+					{Type: gopkg.TypeString{}},
+					{
+						Type: gopkg.TypeNamed{
+							Import: "import/b",
+						},
 					},
 				},
 			},
@@ -1202,6 +1633,64 @@ func TestTypeRequiredImports_CompositeTypes(t *testing.T) {
 				"import/cc": true,
 			},
 		},
+		{
+			Name: "generic with simple type args",
+			Def: gopkg.TypeGeneric{
+				Name:   "Set",
+				Import: "some/import",
+				TypeArgs: []gopkg.Type{
+					gopkg.TypeString{},
+				},
+			},
+			Expected: map[string]bool{
+				"some/import": true,
+			},
+		},
+		{
+			Name: "generic with named type args",
+			Def: gopkg.TypeGeneric{
+				Name: "Set",
+				TypeArgs: []gopkg.Type{
+					gopkg.TypeNamed{
+						Import: "import/a",
+					},
+					gopkg.TypeArray{
+						ValueType: gopkg.TypeNamed{
+							Import: "import/b",
+						},
+					},
+				},
+			},
+			Expected: map[string]bool{
+				"import/a": true,
+				"import/b": true,
+			},
+		},
+		{
+			Name: "union with named terms",
+			Def: gopkg.TypeUnion{
+				Terms: []gopkg.TypeUnionTerm{
+					{
+						Type: gopkg.TypeNamed{
+							Import: "import/a",
+						},
+					},
+					{
+						Type: gopkg.TypeInt{},
+					},
+					{
+						Type: gopkg.TypeNamed{
+							Import: "import/b",
+						},
+						Tilde: true,
+					},
+				},
+			},
+			Expected: map[string]bool{
+				"import/a": true,
+				"import/b": true,
+			},
+		},
 		{
 			Name: "TypeFunc empty",
 			Def:  gopkg.TypeFunc{},
@@ -3,6 +3,7 @@ package gopkg
 import (
 	"errors"
 	"io"
+	"strings"
 	"text/template"
 
 	"github.com/iancoleman/strcase"
@@ -19,9 +20,7 @@ func WriteDeclFunc(
 		return err
 	}
 
-	if decl.DocString != "" {
-		w.Write([]byte(decl.DocString + "\n"))
-	}
+	writeDocAndDirectives(w, "", decl.DocString, decl.Directives)
 
 	w.Write([]byte(funcDecl))
 	w.Write([]byte(" {\n"))
@@ -64,7 +63,18 @@ func fullFuncDecl(
 			decl += "*"
 		}
 
-		decl += f.Receiver.TypeName + ") "
+		decl += f.Receiver.TypeName
+
+		if len(f.Receiver.TypeParams) > 0 {
+			decl += "[" + strings.Join(f.Receiver.TypeParams, ", ") + "]"
+		}
+
+		decl += ") "
+	}
+
+	typeParams, err := typeParamsList(f.TypeParams, importAliases)
+	if err != nil {
+		return "", err
 	}
 
 	argsAndRets, err := funcArgsAndRetArgs(
@@ -78,7 +88,7 @@ func fullFuncDecl(
 		return "", err
 	}
 
-	decl += f.Name + argsAndRets
+	decl += f.Name + typeParams + argsAndRets
 
 	return decl, nil
 }
@@ -469,6 +469,66 @@ func TestWriteDeclFunc(t *testing.T) {
 				"context": "context",
 			},
 		},
+		{
+			Name: "generic func with type params",
+			F: gopkg.DeclFunc{
+				Name: "MapKeys",
+				TypeParams: []gopkg.TypeParam{
+					{
+						Name: "K",
+						Constraint: gopkg.TypeNamed{
+							Name: "comparable",
+						},
+					},
+					{
+						Name: "V",
+						Constraint: gopkg.TypeNamed{
+							Name:   "Number",
+							Import: "some/pkgpath",
+						},
+					},
+				},
+				Args: []gopkg.DeclVar{
+					{
+						Name: "m",
+						Type: gopkg.TypeMap{
+							KeyType:   gopkg.TypeNamed{Name: "K"},
+							ValueType: gopkg.TypeNamed{Name: "V"},
+						},
+					},
+				},
+				ReturnArgs: tmpl.UnnamedReturnArgs(
+					gopkg.TypeGeneric{
+						Name:   "List",
+						Import: "some/pkgpath",
+						TypeArgs: []gopkg.Type{
+							gopkg.TypeNamed{Name: "K"},
+						},
+					},
+				),
+			},
+			ImportAliases: map[string]string{
+				"some/pkgpath": "pkgpath",
+			},
+		},
+		{
+			Name: "pointer reciever of generic type",
+			F: gopkg.DeclFunc{
+				Name: "Add",
+				Receiver: gopkg.FuncReceiver{
+					VarName:    "s",
+					TypeName:   "Set",
+					IsPointer:  true,
+					TypeParams: []string{"K", "_"},
+				},
+				Args: []gopkg.DeclVar{
+					{
+						Name: "k",
+						Type: gopkg.TypeNamed{Name: "K"},
+					},
+				},
+			},
+		},
 		{
 			Name: "using strcase mathods",
 			F: gopkg.DeclFunc{
@@ -23,13 +23,59 @@ func WriteDeclType(
 		return err
 	}
 
-	if decl.DocString != "" {
-		w.Write([]byte(decl.DocString + "\n"))
+	typeParams, err := typeParamsList(decl.TypeParams, importAliases)
+	if err != nil {
+		return err
+	}
+
+	writeDocAndDirectives(w, "", decl.DocString, decl.Directives)
+
+	assign := " "
+	if decl.IsAlias {
+		assign = " = "
 	}
 
 	w.Write([]byte(
-		"type " + decl.Name + " " + fullType + "\n",
+		"type " + decl.Name + typeParams + assign + fullType + "\n",
 	))
 
 	return nil
 }
+
+// typeParamsList returns the type parameter list of a generic declaration,
+// including the enclosing square brackets (e.g. `[K comparable, V any]`)
+//
+// If there are no type params then an empty string is returned.
+func typeParamsList(
+	typeParams []TypeParam,
+	importAliases map[string]string,
+) (string, error) {
+
+	if len(typeParams) == 0 {
+		return "", nil
+	}
+
+	ret := "["
+	for i, p := range typeParams {
+		if p.Name == "" {
+			return "", errors.New("type param name cannot be empty")
+		}
+		if p.Constraint == nil {
+			return "", errors.New("type param constraint cannot be nil")
+		}
+
+		if i > 0 {
+			ret += ", "
+		}
+
+		constraint, err := p.Constraint.FullType(importAliases)
+		if err != nil {
+			return "", err
+		}
+
+		ret += p.Name + " " + constraint
+	}
+	ret += "]"
+
+	return ret, nil
+}
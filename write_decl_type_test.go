@@ -147,6 +147,103 @@ func TestWriteDeclType(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "generic TypeStruct with type params and import aliases",
+			T: gopkg.DeclType{
+				Name: "MyGenericStruct",
+				TypeParams: []gopkg.TypeParam{
+					{
+						Name: "K",
+						Constraint: gopkg.TypeNamed{
+							Name: "comparable",
+						},
+					},
+					{
+						Name: "V",
+						Constraint: gopkg.TypeNamed{
+							Name:   "Number",
+							Import: "some/pkgpath",
+						},
+					},
+				},
+				Type: gopkg.TypeStruct{
+					Fields: []gopkg.DeclVar{
+						{
+							Name: "Items",
+							Type: gopkg.TypeMap{
+								KeyType:   gopkg.TypeNamed{Name: "K"},
+								ValueType: gopkg.TypeNamed{Name: "V"},
+							},
+						},
+					},
+				},
+			},
+			ImportAliases: map[string]string{
+				"some/pkgpath": "pkgpath",
+			},
+		},
+		{
+			Name: "generic TypeArray with union constraint",
+			T: gopkg.DeclType{
+				Name: "Numbers",
+				TypeParams: []gopkg.TypeParam{
+					{
+						Name: "T",
+						Constraint: gopkg.TypeUnion{
+							Terms: []gopkg.TypeUnionTerm{
+								{Type: gopkg.TypeInt{}, Tilde: true},
+								{Type: gopkg.TypeFloat64{}},
+							},
+						},
+					},
+				},
+				Type: gopkg.TypeArray{
+					ValueType: gopkg.TypeNamed{Name: "T"},
+				},
+			},
+		},
+		{
+			Name: "alias of TypeNamed with import aliases",
+			T: gopkg.DeclType{
+				Name: "MyAlias",
+				Type: gopkg.TypeNamed{
+					Name:   "ThingType",
+					Import: "some/otherimport",
+				},
+				IsAlias: true,
+			},
+			ImportAliases: map[string]string{
+				"some/otherimport": "otherimport",
+			},
+		},
+		{
+			Name: "generic alias",
+			T: gopkg.DeclType{
+				Name: "MyGenericAlias",
+				TypeParams: []gopkg.TypeParam{
+					{
+						Name:       "T",
+						Constraint: gopkg.TypeAny{},
+					},
+				},
+				Type: gopkg.TypeMap{
+					KeyType:   gopkg.TypeString{},
+					ValueType: gopkg.TypeNamed{Name: "T"},
+				},
+				IsAlias: true,
+			},
+		},
+		{
+			Name: "type param with no constraint returns error",
+			T: gopkg.DeclType{
+				Name: "MyGeneric",
+				TypeParams: []gopkg.TypeParam{
+					{Name: "T"},
+				},
+				Type: gopkg.TypeStruct{},
+			},
+			ExpectedErr: errors.New("type param constraint cannot be nil"),
+		},
 	}
 
 	for _, test := range testCases {
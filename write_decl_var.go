@@ -3,6 +3,7 @@ package gopkg
 import (
 	"errors"
 	"io"
+	"strings"
 )
 
 func WriteDeclVars(
@@ -16,37 +17,120 @@ func WriteDeclVars(
 		return nil
 	}
 
-	for _, d := range decls {
+	for i, d := range decls {
 		if d.Name == "" {
 			return errors.New("WriteDeclVar: DeclVar.Name cannot be empty")
 		}
 
+		if d.IsImplicit {
+			if i == 0 || decls[i-1].Group != d.Group {
+				return errors.New("WriteDeclVar: implicit DeclVar cannot be first in its group")
+			}
+			continue
+		}
+
+		if d.SharesLiteralValue {
+			if i == 0 || decls[i-1].Group != d.Group {
+				return errors.New("WriteDeclVar: DeclVar sharing a literal value cannot be first in its group")
+			}
+			continue
+		}
+
+		if d.SharesSpec && (i == 0 || decls[i-1].Group != d.Group) {
+			return errors.New("WriteDeclVar: DeclVar sharing a spec cannot be first in its group")
+		}
+
 		if d.Type == nil && d.LiteralValue == "" {
 			return errors.New("WriteDeclVar: one of DeclVar.Type and DeclVar.LiteralValue must be set")
 		}
 	}
 
-	if len(decls) == 1 {
-		if decls[0].DocString != "" {
-			w.Write([]byte(decls[0].DocString + "\n"))
+	for _, group := range groupDeclVars(decls) {
+		err := writeDeclVarGroup(w, keyword, group, importAliases)
+		if err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
+
+// groupDeclVars splits `decls` into runs of consecutive declarations which
+// have the same Group
+func groupDeclVars(decls []DeclVar) [][]DeclVar {
+
+	var groups [][]DeclVar
+	start := 0
+	for i := range decls {
+		if i == len(decls)-1 || decls[i+1].Group != decls[i].Group {
+			groups = append(groups, decls[start:i+1])
+			start = i + 1
+		}
+	}
+	return groups
+}
+
+// declVarSpecs splits the declarations of a single group into the specs
+// they are written as.
+//
+// Declarations which share a spec (e.g. `A, B = iota, iota * 10`) or a
+// multi-valued literal value are written in the same spec as the declaration
+// they follow. Otherwise every declaration is written as its own spec.
+func declVarSpecs(decls []DeclVar) [][]DeclVar {
+
+	var specs [][]DeclVar
+	for i, d := range decls {
+		if i > 0 && (d.SharesSpec || d.SharesLiteralValue) {
+			specs[len(specs)-1] = append(specs[len(specs)-1], d)
+			continue
+		}
+		specs = append(specs, []DeclVar{d})
+	}
+	return specs
+}
+
+func writeDeclVarGroup(
+	w io.Writer,
+	keyword string,
+	decls []DeclVar,
+	importAliases map[string]string,
+) error {
+
+	specs := declVarSpecs(decls)
+
+	first := decls[0]
+	hasGroupDoc := first.GroupDocString != "" || len(first.GroupDirectives) > 0
+	hasSpecDoc := first.DocString != "" || len(first.Directives) > 0
+
+	// A single spec is written without a block, unless both the block and
+	// the spec have their own doc
+	if len(specs) == 1 && !(hasGroupDoc && hasSpecDoc) {
+		writeDocAndDirectives(w, "", first.GroupDocString, first.GroupDirectives)
+		writeDocAndDirectives(w, "", first.DocString, first.Directives)
 		w.Write([]byte(keyword + " "))
-		writeDeclVar(w, decls[0], importAliases)
+		err := writeDeclVar(w, specs[0], importAliases)
+		if err != nil {
+			return err
+		}
 		w.Write([]byte("\n"))
 		return nil
 	}
 
+	writeDocAndDirectives(w, "", first.GroupDocString, first.GroupDirectives)
 	w.Write([]byte(keyword + " (\n"))
-	for i, d := range decls {
-		if d.DocString != "" {
+	for i, spec := range specs {
+		if spec[0].DocString != "" || len(spec[0].Directives) > 0 {
 			// vanity space var/const declarations if there is a docstring
 			if i != 0 {
 				w.Write([]byte("\n"))
 			}
-			w.Write([]byte("\t" + d.DocString + "\n"))
+			writeDocAndDirectives(w, "\t", spec[0].DocString, spec[0].Directives)
 		}
 		w.Write([]byte("\t"))
-		writeDeclVar(w, d, importAliases)
+		err := writeDeclVar(w, spec, importAliases)
+		if err != nil {
+			return err
+		}
 	}
 
 	w.Write([]byte(")\n\n"))
@@ -54,13 +138,33 @@ func WriteDeclVars(
 	return nil
 }
 
+// writeDeclVar writes a single spec declaring one or more names; the type is
+// taken from the first declaration in the spec
 func writeDeclVar(
 	w io.Writer,
-	d DeclVar,
+	spec []DeclVar,
 	importAliases map[string]string,
 ) error {
 
-	w.Write([]byte(d.Name))
+	names := make([]string, 0, len(spec))
+	values := make([]string, 0, len(spec))
+	for _, d := range spec {
+		names = append(names, d.Name)
+		if d.LiteralValue != "" {
+			values = append(values, d.LiteralValue)
+		}
+	}
+
+	w.Write([]byte(strings.Join(names, ", ")))
+
+	d := spec[0]
+	if d.IsImplicit {
+		if d.Comment != "" {
+			w.Write([]byte(" " + d.Comment))
+		}
+		w.Write([]byte("\n"))
+		return nil
+	}
 
 	if d.Type != nil {
 		if _, isLiteral := d.Type.(TypeUnnamedLiteral); !isLiteral {
@@ -73,8 +177,12 @@ func writeDeclVar(
 		}
 	}
 
-	if d.LiteralValue != "" {
-		w.Write([]byte(" = " + d.LiteralValue))
+	if len(values) > 0 {
+		w.Write([]byte(" = " + strings.Join(values, ", ")))
+	}
+
+	if d.Comment != "" {
+		w.Write([]byte(" " + d.Comment))
 	}
 
 	w.Write([]byte("\n"))
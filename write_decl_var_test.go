@@ -48,6 +48,48 @@ func TestWriteDeclVars(t *testing.T) {
 			},
 			ExpectedErr: errors.New("WriteDeclVar: one of DeclVar.Type and DeclVar.LiteralValue must be set"),
 		},
+		{
+			Name: "implicit var first in group returns error",
+			Vars: []gopkg.DeclVar{
+				{
+					Name:         "A",
+					Type:         gopkg.TypeInt{},
+					LiteralValue: "iota",
+				},
+				{
+					Name:         "B",
+					Type:         gopkg.TypeInt{},
+					LiteralValue: "iota",
+					Group:        1,
+					IsImplicit:   true,
+				},
+			},
+			ExpectedErr: errors.New("WriteDeclVar: implicit DeclVar cannot be first in its group"),
+		},
+		{
+			Name:    "decl sharing a literal value first in group returns error",
+			Keyword: "var",
+			Vars: []gopkg.DeclVar{
+				{
+					Name:               "Err",
+					Type:               gopkg.TypeUnnamedLiteral{},
+					SharesLiteralValue: true,
+				},
+			},
+			ExpectedErr: errors.New("WriteDeclVar: DeclVar sharing a literal value cannot be first in its group"),
+		},
+		{
+			Name:    "decl sharing a spec first in group returns error",
+			Keyword: "var",
+			Vars: []gopkg.DeclVar{
+				{
+					Name:       "B",
+					Type:       gopkg.TypeInt{},
+					SharesSpec: true,
+				},
+			},
+			ExpectedErr: errors.New("WriteDeclVar: DeclVar sharing a spec cannot be first in its group"),
+		},
 		{
 			Name:    "single var with built in type",
 			Keyword: "var",
@@ -150,6 +192,96 @@ func TestWriteDeclVars(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name:    "consts in separate groups",
+			Keyword: "const",
+			Vars: []gopkg.DeclVar{
+				{
+					Name:         "A",
+					Type:         gopkg.TypeUnnamedLiteral{},
+					LiteralValue: "1",
+				},
+				{
+					Name:         "B",
+					Type:         gopkg.TypeUnnamedLiteral{},
+					LiteralValue: "2",
+				},
+				{
+					Name:         "C",
+					Type:         gopkg.TypeInt{},
+					LiteralValue: "3",
+					Group:        1,
+				},
+				{
+					Name:         "D",
+					Type:         gopkg.TypeUnnamedLiteral{},
+					LiteralValue: "4",
+					Group:        2,
+				},
+				{
+					Name:         "E",
+					Type:         gopkg.TypeUnnamedLiteral{},
+					LiteralValue: "5",
+					Group:        2,
+				},
+			},
+		},
+		{
+			Name:    "consts with iota and implicit repetition",
+			Keyword: "const",
+			Vars: []gopkg.DeclVar{
+				{
+					Name:         "MyEnumUnknown",
+					Type:         gopkg.TypeNamed{Name: "MyEnum"},
+					LiteralValue: "iota",
+				},
+				{
+					Name:         "MyEnumOne",
+					Type:         gopkg.TypeNamed{Name: "MyEnum"},
+					LiteralValue: "iota",
+					Iota:         1,
+					IsImplicit:   true,
+				},
+				{
+					Name:         "MyEnumTwo",
+					Type:         gopkg.TypeNamed{Name: "MyEnum"},
+					LiteralValue: "iota",
+					Iota:         2,
+					IsImplicit:   true,
+				},
+				{
+					Name:         "KiB",
+					Type:         gopkg.TypeUnnamedLiteral{},
+					LiteralValue: "1 << (10 * (iota + 1))",
+					Group:        1,
+				},
+				{
+					Name:         "MiB",
+					Type:         gopkg.TypeUnnamedLiteral{},
+					LiteralValue: "1 << (10 * (iota + 1))",
+					Group:        1,
+					Iota:         1,
+					IsImplicit:   true,
+				},
+			},
+		},
+		{
+			Name:    "vars with directives",
+			Keyword: "var",
+			Vars: []gopkg.DeclVar{
+				{
+					Name:       "hello",
+					Type:       gopkg.TypeString{},
+					Directives: []string{"//go:embed hello.txt"},
+				},
+				{
+					Name:       "files",
+					Type:       gopkg.TypeString{},
+					DocString:  "// files holds the static files",
+					Directives: []string{"//go:embed static/*.txt"},
+				},
+			},
+		},
 	}
 
 	for _, test := range testCases {
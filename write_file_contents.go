@@ -1,8 +1,13 @@
 package gopkg
 
 import (
+	"bytes"
 	"errors"
+	"go/build/constraint"
+	"go/format"
 	"io"
+	"strconv"
+	"strings"
 )
 
 func WriteFileContents(
@@ -14,15 +19,51 @@ func WriteFileContents(
 		return errors.New("package name cannot be empty")
 	}
 
+	if c.GeneratedBy != "" {
+		w.Write([]byte(generatedHeader(c.GeneratedBy) + "\n\n"))
+	}
+
+	if c.BuildConstraint != "" {
+		_, err := constraint.Parse("//go:build " + c.BuildConstraint)
+		if err != nil {
+			return errors.New("invalid build constraint '" + c.BuildConstraint + "'")
+		}
+		w.Write([]byte("//go:build " + c.BuildConstraint + "\n\n"))
+	}
+
+	if len(c.Directives) > 0 {
+		writeDocAndDirectives(w, "", "", c.Directives)
+		w.Write([]byte("\n"))
+	}
+
 	if c.DocString != "" {
 		w.Write([]byte(c.DocString + "\n"))
 	}
 
 	w.Write([]byte("package " + c.PackageName + "\n\n"))
 
-	if len(c.Imports) > 0 {
+	// cgo requires `import "C"` to be in its own import declaration,
+	// immediately after the preamble
+	imports := make([]ImportAndAlias, 0, len(c.Imports))
+	importsC := c.CgoPreamble != ""
+	for _, i := range c.Imports {
+		if i.Import == "C" {
+			importsC = true
+			continue
+		}
+		imports = append(imports, i)
+	}
+
+	if importsC {
+		if c.CgoPreamble != "" {
+			w.Write([]byte(c.CgoPreamble + "\n"))
+		}
+		w.Write([]byte("import \"C\"\n\n"))
+	}
+
+	if len(imports) > 0 {
 		w.Write([]byte("import (\n"))
-		for _, i := range c.Imports {
+		for _, i := range imports {
 			w.Write([]byte("\t" + i.Alias + " \"" + i.Import + "\"\n"))
 		}
 		w.Write([]byte(")\n\n"))
@@ -59,10 +100,62 @@ func WriteFileContents(
 	return nil
 }
 
+// WriteFormattedFileContents writes `c` in the same way as WriteFileContents
+// but passes the output through gofmt (`go/format`) before writing it to `w`.
+//
+// If the generated source is not syntactically valid Go (e.g. because of a
+// broken BodyTmpl) nothing is written and the returned error contains the
+// generated source with line numbers.
+func WriteFormattedFileContents(
+	w io.Writer,
+	c FileContents,
+) error {
+
+	buffer := bytes.NewBuffer(nil)
+	err := WriteFileContents(buffer, c)
+	if err != nil {
+		return err
+	}
+
+	formatted, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return errors.New(
+			"failed to format generated source for '" + c.Filepath + "': " +
+				err.Error() + "\n" + withLineNumbers(buffer.String()),
+		)
+	}
+
+	_, err = w.Write(formatted)
+	return err
+}
+
+// withLineNumbers returns `src` with each line prefixed by its line number
+func withLineNumbers(src string) string {
+
+	lines := strings.Split(strings.TrimSuffix(src, "\n"), "\n")
+	width := len(strconv.Itoa(len(lines)))
+
+	var ret strings.Builder
+	for i, line := range lines {
+		lineNum := strconv.Itoa(i + 1)
+		ret.WriteString(strings.Repeat(" ", width-len(lineNum)) + lineNum + ":")
+		if line != "" {
+			ret.WriteString(" " + line)
+		}
+		ret.WriteString("\n")
+	}
+	return ret.String()
+}
+
 func importsToImportAliasMap(imports []ImportAndAlias) map[string]string {
 
 	ret := make(map[string]string)
 	for _, i := range imports {
+		// Declarations from dot imports are referenced without an alias and
+		// declarations from blank imports cannot be referenced at all
+		if i.Alias == "." || i.Alias == "_" {
+			continue
+		}
 		ret[i.Import] = i.Alias
 	}
 	return ret
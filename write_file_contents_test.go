@@ -29,6 +29,33 @@ func TestWriteFileContents(t *testing.T) {
 				PackageName: "mypkg",
 			},
 		},
+		{
+			Name: "dot and blank imports",
+			C: gopkg.FileContents{
+				PackageName: "dot_and_blank",
+				Imports: []gopkg.ImportAndAlias{
+					{Import: "github.com/lib/pq", Alias: "_"},
+					{Import: "some/dot/pkg", Alias: "."},
+					{Import: "some/pkg/path", Alias: "pkg_path"},
+				},
+				Vars: []gopkg.DeclVar{
+					{
+						Name: "a",
+						Type: gopkg.TypeNamed{
+							Name:   "DotType",
+							Import: "some/dot/pkg",
+						},
+					},
+					{
+						Name: "b",
+						Type: gopkg.TypeNamed{
+							Name:   "OtherType",
+							Import: "some/pkg/path",
+						},
+					},
+				},
+			},
+		},
 		{
 			Name: "imports with a struct type decl and a function decl",
 			C: gopkg.FileContents{
@@ -193,6 +220,60 @@ func TestWriteFileContents(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "generated file with build constraint and doc string",
+			C: gopkg.FileContents{
+				PackageName:     "mypackage",
+				DocString:       "// Package mypackage does things",
+				GeneratedBy:     "mygenerator",
+				BuildConstraint: "linux && (amd64 || arm64)",
+			},
+		},
+		{
+			Name: "directives and cgo preamble",
+			C: gopkg.FileContents{
+				PackageName: "mypackage",
+				DocString:   "// Package mypackage does things",
+				Directives: []string{
+					"//go:generate go run ./gen",
+				},
+				CgoPreamble: "// #include <stdlib.h>",
+				Imports: []gopkg.ImportAndAlias{
+					{Import: "C"},
+					{Import: "embed", Alias: "_"},
+				},
+				Vars: []gopkg.DeclVar{
+					{
+						Name:       "Hello",
+						Type:       gopkg.TypeString{},
+						DocString:  "// Hello is embedded",
+						Directives: []string{"//go:embed hello.txt"},
+					},
+				},
+				Functions: []gopkg.DeclFunc{
+					{
+						Name:       "Free",
+						Directives: []string{"//export Free"},
+					},
+				},
+			},
+		},
+		{
+			Name: "invalid build constraint returns error",
+			C: gopkg.FileContents{
+				PackageName:     "mypackage",
+				BuildConstraint: "linux &&",
+			},
+			ExpectedErr: errors.New("invalid build constraint 'linux &&'"),
+		},
+		{
+			Name: "generated file with doc string",
+			C: gopkg.FileContents{
+				PackageName: "mypackage",
+				DocString:   "// Package mypackage does things",
+				GeneratedBy: "mygenerator",
+			},
+		},
 	}
 
 	for _, test := range testCases {
@@ -217,3 +298,112 @@ func TestWriteFileContents(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteFormattedFileContents(t *testing.T) {
+
+	testCases := []struct {
+		Name        string
+		C           gopkg.FileContents
+		ExpectedErr error
+	}{
+		{
+			Name:        "empty package name returns error",
+			ExpectedErr: errors.New("package name cannot be empty"),
+		},
+		{
+			Name: "struct fields, var blocks and func bodies are formatted",
+			C: gopkg.FileContents{
+				PackageName: "mypackage",
+				Imports: []gopkg.ImportAndAlias{
+					{Import: "context", Alias: "context"},
+				},
+				Vars: []gopkg.DeclVar{
+					{
+						Name:         "a",
+						Type:         gopkg.TypeInt{},
+						LiteralValue: "1",
+					},
+					{
+						Name:         "someLongerName",
+						Type:         gopkg.TypeString{},
+						LiteralValue: `"b"`,
+					},
+				},
+				Types: []gopkg.DeclType{
+					{
+						Name: "MyStruct",
+						Type: gopkg.TypeStruct{
+							Fields: []gopkg.DeclVar{
+								{
+									Name: "A",
+									Type: gopkg.TypeInt{},
+								},
+								{
+									Name: "SomeContext",
+									Type: gopkg.TypeNamed{
+										Name:   "Context",
+										Import: "context",
+									},
+									StructTag: `json:"some_context"`,
+								},
+							},
+						},
+					},
+				},
+				Functions: []gopkg.DeclFunc{
+					{
+						Name:     "MyFunc",
+						BodyTmpl: "\nif a==1 {\nreturn\n}\n",
+					},
+				},
+			},
+		},
+		{
+			Name: "syntax error returns error with numbered source",
+			C: gopkg.FileContents{
+				Filepath:    "some/path/broken.go",
+				PackageName: "broken",
+				Functions: []gopkg.DeclFunc{
+					{
+						Name:     "Broken",
+						BodyTmpl: "\n\treturn (\n",
+					},
+				},
+			},
+			ExpectedErr: errors.New(
+				"failed to format generated source for 'some/path/broken.go': " +
+					"6:1: expected operand, found '}' (and 1 more errors)\n" +
+					"1: package broken\n" +
+					"2:\n" +
+					"3: func Broken() {\n" +
+					"4:\n" +
+					"5: \treturn (\n" +
+					"6: }\n" +
+					"7:\n",
+			),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+
+			buffer := bytes.NewBuffer(nil)
+
+			err := gopkg.WriteFormattedFileContents(
+				buffer,
+				test.C,
+			)
+
+			if test.ExpectedErr != nil {
+				require.Equal(t, test.ExpectedErr, err)
+				require.Equal(t, 0, buffer.Len())
+				return
+			}
+
+			require.NoError(t, err)
+
+			g := goldie.New(t)
+			g.Assert(t, t.Name(), buffer.Bytes())
+		})
+	}
+}